@@ -0,0 +1,56 @@
+package amt8000test
+
+import "time"
+
+// SetClock sets the panel clock, which then keeps ticking from t.
+func (p *Panel) SetClock(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.drift = time.Until(t)
+}
+
+// Clock returns the panel clock.
+func (p *Panel) Clock() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.clock()
+}
+
+// Must be called with the lock held.
+func (p *Panel) clock() time.Time {
+	return time.Now().Add(p.drift)
+}
+
+// Must be called with the lock held.
+func (p *Panel) time() []byte {
+	t := p.clock().In(time.Local)
+	return []byte{
+		byte(t.Day()),
+		byte(t.Month()),
+		byte(t.Year() - 2000),
+		byte(t.Hour()),
+		byte(t.Minute()),
+		byte(t.Second()),
+	}
+}
+
+// setTime sets the clock, which also clears the clock trouble.
+// Must be called with the lock held.
+func (p *Panel) setTime(data []byte) error {
+	if len(data) != 6 {
+		return errInvalidPacket
+	}
+	t := time.Date(
+		2000+int(data[2]), time.Month(data[1]), int(data[0]),
+		int(data[3]), int(data[4]), int(data[5]), 0,
+		time.Local,
+	)
+	if t.Day() != int(data[0]) || t.Month() != time.Month(data[1]) ||
+		t.Hour() != int(data[3]) || t.Minute() != int(data[4]) || t.Second() != int(data[5]) {
+		return errInvalidPacket
+	}
+	p.drift = time.Until(t)
+	p.troubles.ClockNotSet = false
+	p.addLogEntry(LogEntry{Code: 625})
+	return nil
+}
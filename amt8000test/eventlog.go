@@ -0,0 +1,62 @@
+package amt8000test
+
+import "time"
+
+// LogEntry is an entry in the emulated event log.
+type LogEntry struct {
+	Time      time.Time
+	Code      int
+	Restore   bool
+	Partition int
+	Zone      int
+	User      int
+}
+
+// AddLogEntry adds an entry to the top of the event log.
+// Arming, disarming and panics done through the panel are logged
+// automatically.
+func (p *Panel) AddLogEntry(e LogEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addLogEntry(e)
+}
+
+// Must be called with the lock held.
+func (p *Panel) addLogEntry(e LogEntry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	p.eventLog = append([]LogEntry{e}, p.eventLog...)
+}
+
+// Must be called with the lock held.
+func (p *Panel) events(data []byte) ([]byte, error) {
+	if len(data) != 3 {
+		return nil, errInvalidPacket
+	}
+	from := int(data[0])<<8 | int(data[1])
+	count := int(data[2])
+
+	var buf []byte
+	for i := from; i < from+count && i < len(p.eventLog); i++ {
+		e := p.eventLog[i]
+		qualifier := 1
+		if e.Restore {
+			qualifier = 3
+		}
+		buf = append(buf, encodeDigits(qualifier, 1)...)
+		buf = append(buf, encodeDigits(e.Code, 3)...)
+		buf = append(buf,
+			byte(e.Partition),
+			byte(e.Zone),
+			byte(e.User),
+			byte(e.Time.Day()),
+			byte(e.Time.Month()),
+			byte(e.Time.Year()-2000),
+			byte(e.Time.Hour()),
+			byte(e.Time.Minute()),
+			byte(e.Time.Second()),
+		)
+	}
+	return buf, nil
+}
@@ -0,0 +1,54 @@
+package amt8000test
+
+const nameSize = 16
+
+// SetZoneName sets the name of the given zone, from 1 to 64.
+func (p *Panel) SetZoneName(n int, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.zoneNames[n-1] = name
+}
+
+// SetPartitionName sets the name of the given partition, numbered as in the
+// status reply, from 0 to 15.
+func (p *Panel) SetPartitionName(n int, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.partitionNames[n] = name
+}
+
+// SetUserName sets the name of the given user, from 1 to 98.
+func (p *Panel) SetUserName(n int, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.userNames[n-1] = name
+}
+
+// Must be called with the lock held.
+func (p *Panel) names(data []byte) ([]byte, error) {
+	if len(data) != 3 {
+		return nil, errInvalidPacket
+	}
+	var names []string
+	switch data[0] {
+	case 0x01:
+		names = p.zoneNames[:]
+	case 0x02:
+		names = p.partitionNames[:]
+	case 0x03:
+		names = p.userNames[:]
+	default:
+		return nil, errInvalidPacket
+	}
+
+	var buf []byte
+	for i := int(data[1]); i < int(data[1])+int(data[2]) && i < len(names); i++ {
+		name := make([]byte, nameSize)
+		for j := range name {
+			name[j] = ' '
+		}
+		copy(name, names[i])
+		buf = append(buf, name...)
+	}
+	return buf, nil
+}
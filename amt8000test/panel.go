@@ -0,0 +1,672 @@
+// Package amt8000test provides an in-process emulator of an Intelbras
+// AMT-8000 alarm system speaking ISECNet v2, so the client and the bridge can
+// be tested without the real thing.
+package amt8000test
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Zone is the emulated state of a zone.
+type Zone struct {
+	Enabled    bool
+	Open       bool
+	Violated   bool
+	Bypassed   bool
+	Tamper     bool
+	LowBattery bool
+	Firing     bool
+	Fired      bool
+
+	// Whether the wireless zone stopped reporting to the panel.
+	SupervisionLoss bool
+
+	// RF signal level, from 0 to 10.
+	SignalLevel int
+
+	// Partition the zone belongs to.
+	// Zones in partition 0 belong to all of them.
+	Partition int
+
+	// How the zone is programmed: its function and sensor type codes, and
+	// whether it is a stay zone.
+	Function int
+	Sensor   int
+	Stay     bool
+}
+
+// Partition is the emulated state of a partition.
+type Partition struct {
+	Enabled    bool
+	Armed      bool
+	Stay       bool
+	Firing     bool
+	Fired      bool
+	ExitDelay  bool
+	EntryDelay bool
+}
+
+// Troubles are the emulated trouble conditions of the panel.
+type Troubles struct {
+	ACLoss      bool
+	Ethernet    bool
+	GPRS        bool
+	PhoneLine   bool
+	ClockNotSet bool
+	RFJamming   bool
+
+	// Sirens and repeaters, from 1 to 2, that lost supervision.
+	// Zones lose it with Zone.SupervisionLoss.
+	Sirens    []int
+	Repeaters []int
+}
+
+// Panel is a fake alarm system listening on a local port.
+type Panel struct {
+	// Password is the remote programming password clients must use.
+	Password string
+
+	listener net.Listener
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mu         sync.Mutex
+	conns      map[net.Conn]*clientConn
+	delay      time.Duration
+	auths      int
+	zones      [64]Zone
+	partitions [16]Partition
+	siren      bool
+	tamper     bool
+	pgms       [16]bool
+	drift      time.Duration
+	exitDelay  bool
+	exitTime   time.Duration
+	entryTimes [16]time.Duration
+	troubles   Troubles
+	model      byte
+	version    [3]byte
+	statusSize int
+	levelsSize int
+	commands   []int
+	unknown    map[int]bool
+	ignored    map[int]bool
+	eventLog   []LogEntry
+
+	zoneNames      [64]string
+	partitionNames [16]string
+	userNames      [98]string
+	users          [98]User
+}
+
+// NewPanel starts a new emulated alarm system listening on a random local
+// port.
+// All zones are disabled and partition 1 is enabled and disarmed.
+// Callers must Close it when done.
+func NewPanel(password string) *Panel {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("amt8000test: failed to listen: " + err.Error())
+	}
+	p := &Panel{
+		Password:   password,
+		listener:   l,
+		done:       make(chan struct{}),
+		conns:      map[net.Conn]*clientConn{},
+		model:      0x01,
+		version:    [3]byte{2, 5, 3},
+		statusSize: 143,
+		levelsSize: 68,
+	}
+	p.partitions[1].Enabled = true
+	p.wg.Add(1)
+	go p.serve()
+	return p
+}
+
+// Host returns the host the panel is listening on.
+func (p *Panel) Host() string {
+	host, _, _ := net.SplitHostPort(p.listener.Addr().String())
+	return host
+}
+
+// Port returns the port the panel is listening on.
+func (p *Panel) Port() string {
+	_, port, _ := net.SplitHostPort(p.listener.Addr().String())
+	return port
+}
+
+// Close stops the panel, closing all open connections.
+func (p *Panel) Close() {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	close(p.done)
+	_ = p.listener.Close()
+	p.DropConnections()
+	p.wg.Wait()
+}
+
+// DropConnections closes all currently open connections, as if the network
+// went away.
+func (p *Panel) DropConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+}
+
+// Event is an event the panel can send to its clients.
+type Event struct {
+	Account   int
+	Code      int
+	Restore   bool
+	Partition int
+	Zone      int
+}
+
+// Notify sends the given event to all authenticated clients.
+func (p *Panel) Notify(e Event) {
+	qualifier := 1
+	if e.Restore {
+		qualifier = 3
+	}
+	data := encodeDigits(e.Account, 4)
+	data = append(data, encodeDigits(qualifier, 1)...)
+	data = append(data, encodeDigits(e.Code, 3)...)
+	data = append(data, encodeDigits(e.Partition, 2)...)
+	data = append(data, encodeDigits(e.Zone, 3)...)
+
+	p.mu.Lock()
+	var conns []*clientConn
+	for _, c := range p.conns {
+		if c.authenticated {
+			conns = append(conns, c)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.write(frame{dst: c.peer, cmd: cmdEvent, data: data})
+	}
+}
+
+// SetDelay makes the panel wait the given duration before every reply.
+// Use a long delay to simulate timeouts.
+func (p *Panel) SetDelay(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.delay = d
+}
+
+// Auths returns how many successful authentications the panel got.
+func (p *Panel) Auths() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.auths
+}
+
+// Commands returns all commands the panel received, in order.
+func (p *Panel) Commands() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]int(nil), p.commands...)
+}
+
+// SetZone sets the state of the given zone, from 1 to 64.
+func (p *Panel) SetZone(n int, z Zone) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.zones[n-1] = z
+}
+
+// Zone returns the state of the given zone, from 1 to 64.
+func (p *Panel) Zone(n int) Zone {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.zones[n-1]
+}
+
+// SetPartition sets the state of the given partition, numbered as in the
+// status reply, from 0 to 15.
+func (p *Panel) SetPartition(n int, part Partition) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.partitions[n] = part
+}
+
+// Partition returns the state of the given partition, numbered as in the
+// status reply, from 0 to 15.
+func (p *Panel) Partition(n int) Partition {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.partitions[n]
+}
+
+// SetSiren sets whether the siren is on.
+func (p *Panel) SetSiren(on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.siren = on
+}
+
+// Siren tells whether the siren is on.
+func (p *Panel) Siren() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.siren
+}
+
+// SetTamper sets the panel tamper status.
+func (p *Panel) SetTamper(tamper bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tamper = tamper
+}
+
+// SetTroubles sets the panel trouble conditions.
+func (p *Panel) SetTroubles(troubles Troubles) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.troubles = troubles
+}
+
+// SetPGM turns the given PGM, from 1 to 16, on or off.
+func (p *Panel) SetPGM(n int, on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pgms[n-1] = on
+}
+
+// PGM tells whether the given PGM, from 1 to 16, is on.
+func (p *Panel) PGM(n int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pgms[n-1]
+}
+
+// SetExitDelay makes arming go through the exit delay: partitions are only
+// armed once EndExitDelay is called.
+func (p *Panel) SetExitDelay(on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exitDelay = on
+}
+
+// EndExitDelay arms the partitions whose exit delay is running.
+func (p *Panel) EndExitDelay() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.partitions {
+		if p.partitions[i].ExitDelay {
+			p.partitions[i].ExitDelay = false
+			p.partitions[i].Armed = true
+		}
+	}
+}
+
+// SetModel sets the model code and firmware version the panel reports.
+func (p *Panel) SetModel(model byte, major, minor, patch byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.model = model
+	p.version = [3]byte{major, minor, patch}
+}
+
+// SetStatusSize makes the panel send statuses of the given size, truncating
+// or padding the AMT-8000 layout, as other models and firmwares do.
+func (p *Panel) SetStatusSize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statusSize = size
+}
+
+// SetSignalLevelsSize makes the panel send signal levels of the given size,
+// truncating or padding the AMT-8000 layout.
+func (p *Panel) SetSignalLevelsSize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.levelsSize = size
+}
+
+// Unsupported makes the panel refuse the given commands as invalid, as an
+// older firmware would.
+func (p *Panel) Unsupported(cmds ...int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unknown == nil {
+		p.unknown = map[int]bool{}
+	}
+	for _, cmd := range cmds {
+		p.unknown[cmd] = true
+	}
+}
+
+// Ignore makes the panel never reply to the given commands, as some firmwares
+// do with commands they don't know.
+func (p *Panel) Ignore(cmds ...int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ignored == nil {
+		p.ignored = map[int]bool{}
+	}
+	for _, cmd := range cmds {
+		p.ignored[cmd] = true
+	}
+}
+
+func (p *Panel) serve() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &clientConn{Conn: conn}
+		p.mu.Lock()
+		p.conns[conn] = c
+		p.mu.Unlock()
+
+		p.wg.Add(1)
+		go p.handle(c)
+	}
+}
+
+// clientConn is a client connection to the panel.
+type clientConn struct {
+	net.Conn
+
+	// guards writes, as events might be written while replying.
+	mu            sync.Mutex
+	authenticated bool
+	peer          int
+}
+
+func (c *clientConn) write(f frame) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.Write(f.bytes())
+	return err
+}
+
+func (p *Panel) handle(conn *clientConn) {
+	defer p.wg.Done()
+	defer func() {
+		p.mu.Lock()
+		delete(p.conns, conn.Conn)
+		p.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		req, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		p.mu.Lock()
+		p.commands = append(p.commands, req.cmd)
+		delay := p.delay
+		ignored := p.ignored[req.cmd]
+		p.mu.Unlock()
+
+		if req.cmd == cmdDisconnect {
+			return
+		}
+		if ignored {
+			continue
+		}
+
+		var cmd int
+		var data []byte
+		switch {
+		case req.cmd == cmdAuth:
+			cmd, data = p.auth(req)
+			p.mu.Lock()
+			conn.authenticated = data[0] == 0x00
+			conn.peer = req.src
+			p.mu.Unlock()
+		case !conn.authenticated:
+			cmd, data = cmdNack, []byte{nackInvalidPacket}
+		default:
+			cmd, data = p.exec(req)
+		}
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-p.done:
+				return
+			}
+		}
+
+		reply := frame{dst: req.src, src: req.dst, cmd: cmd, data: data}
+		if err := conn.write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func (p *Panel) auth(req frame) (int, []byte) {
+	// device type, 6 password digits, software version.
+	if len(req.data) != 8 || !bytes.Equal(req.data[1:7], encodePassword(p.Password)) {
+		return cmdAuth, []byte{0x01}
+	}
+	p.mu.Lock()
+	p.auths++
+	p.mu.Unlock()
+	return cmdAuth, []byte{0x00}
+}
+
+func (p *Panel) exec(req frame) (int, []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.unknown[req.cmd] {
+		return cmdNack, []byte{nackInvalidCommand}
+	}
+
+	var err error
+	switch req.cmd {
+	case cmdStatus:
+		return cmdStatus, p.status()
+	case cmdSignalLevels:
+		return cmdSignalLevels, p.signalLevels()
+	case cmdTime:
+		return cmdTime, p.time()
+	case cmdSetTime:
+		err = p.setTime(req.data)
+	case cmdUsers:
+		var data []byte
+		data, err = p.listUsers(req.data)
+		if err == nil {
+			return cmdUsers, data
+		}
+	case cmdUser:
+		err = p.setUser(req.data)
+	case cmdProgramming:
+		return cmdProgramming, p.programming()
+	case cmdNames:
+		var data []byte
+		data, err = p.names(req.data)
+		if err == nil {
+			return cmdNames, data
+		}
+	case cmdEventLog:
+		var data []byte
+		data, err = p.events(req.data)
+		if err == nil {
+			return cmdEventLog, data
+		}
+	case cmdArm:
+		err = p.arm(req.data)
+	case cmdBypass:
+		err = p.bypass(req.data)
+	case cmdPanic:
+		err = p.panic(req.data)
+	case cmdPGM:
+		err = p.pgm(req.data)
+	case cmdTurnOffSiren:
+		p.siren = false
+	case cmdCleanFiring:
+		for i := range p.partitions {
+			p.partitions[i].Firing = false
+			p.partitions[i].Fired = false
+		}
+		for i := range p.zones {
+			p.zones[i].Firing = false
+			p.zones[i].Fired = false
+		}
+	default:
+		err = errInvalidCommand
+	}
+
+	if nack, ok := err.(nackError); ok {
+		return cmdNack, []byte{byte(nack)}
+	}
+	return req.cmd, nil
+}
+
+// nackError makes the panel refuse a command with the given reason.
+type nackError byte
+
+func (e nackError) Error() string {
+	return fmt.Sprintf("nack: %#02x", byte(e))
+}
+
+var (
+	errInvalidPacket  error = nackError(nackInvalidPacket)
+	errInvalidCommand error = nackError(nackInvalidCommand)
+	errNotPartitioned error = nackError(nackNotPartitioned)
+	errOpenZones      error = nackError(nackOpenZones)
+	errBypassArmed    error = nackError(nackBypassArmed)
+)
+
+func (p *Panel) arm(data []byte) error {
+	if len(data) != 2 {
+		return errInvalidPacket
+	}
+	partitions := p.targetPartitions(data[0])
+	if len(partitions) == 0 {
+		return errNotPartitioned
+	}
+
+	switch data[1] {
+	case subCmdDisarm:
+		for _, n := range partitions {
+			p.partitions[n].Armed = false
+			p.partitions[n].Stay = false
+			p.partitions[n].Firing = false
+			p.partitions[n].ExitDelay = false
+			p.partitions[n].EntryDelay = false
+			p.addLogEntry(LogEntry{Code: 401, Partition: n})
+		}
+		p.siren = false
+	case subCmdArm, subCmdStay:
+		for _, n := range partitions {
+			if len(p.openZones(n)) > 0 {
+				return errOpenZones
+			}
+		}
+		for _, n := range partitions {
+			p.partitions[n].Armed = !p.exitDelay
+			p.partitions[n].ExitDelay = p.exitDelay
+			p.partitions[n].Stay = data[1] == subCmdStay
+			p.addLogEntry(LogEntry{Code: 401, Restore: true, Partition: n})
+		}
+	default:
+		return errInvalidPacket
+	}
+	return nil
+}
+
+func (p *Panel) bypass(data []byte) error {
+	if len(data) != 2 || int(data[0]) >= len(p.zones) {
+		return errInvalidPacket
+	}
+	if p.zoneArmed(p.zones[data[0]]) {
+		return errBypassArmed
+	}
+	p.zones[data[0]].Bypassed = data[1] == 0x01
+	return nil
+}
+
+// Must be called with the lock held.
+func (p *Panel) pgm(data []byte) error {
+	if len(data) != 2 || int(data[0]) >= len(p.pgms) {
+		return errInvalidPacket
+	}
+	p.pgms[data[0]] = data[1] == 0x01
+	return nil
+}
+
+// panics are the Contact ID codes logged for each panic type, and whether
+// they sound the siren.
+var panics = map[byte]struct {
+	code  int
+	siren bool
+}{
+	0x01: {122, false},
+	0x02: {120, true},
+	0x03: {110, true},
+	0x04: {100, false},
+}
+
+// Must be called with the lock held.
+func (p *Panel) panic(data []byte) error {
+	if len(data) != 2 {
+		return errInvalidPacket
+	}
+	kind, ok := panics[data[0]]
+	if !ok {
+		return errInvalidPacket
+	}
+	p.siren = p.siren || kind.siren
+	p.addLogEntry(LogEntry{Code: kind.code})
+	return nil
+}
+
+func (p *Panel) targetPartitions(n byte) []int {
+	var result []int
+	for i := range p.partitions {
+		if !p.partitions[i].Enabled {
+			continue
+		}
+		if n == allPartitions || int(n) == i {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// zoneArmed tells whether any of the partitions the zone belongs to is armed.
+func (p *Panel) zoneArmed(z Zone) bool {
+	for i, part := range p.partitions {
+		if part.Armed && (z.Partition == 0 || z.Partition == i) {
+			return true
+		}
+	}
+	return false
+}
+
+// openZones returns the zones, from 1 to 64, that prevent the given partition
+// from being armed.
+func (p *Panel) openZones(partition int) []int {
+	var result []int
+	for i, z := range p.zones {
+		if !z.Enabled || z.Bypassed || !(z.Open || z.Violated) {
+			continue
+		}
+		if z.Partition == 0 || z.Partition == partition {
+			result = append(result, i+1)
+		}
+	}
+	return result
+}
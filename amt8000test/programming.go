@@ -0,0 +1,33 @@
+package amt8000test
+
+import "time"
+
+// SetDelays sets the exit delay, and the entry delay of the given
+// partitions, as reported in the programming.
+// They are only reported: arming only goes through the exit delay when
+// SetExitDelay is on.
+func (p *Panel) SetDelays(exit time.Duration, entry map[int]time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exitTime = exit
+	for part, d := range entry {
+		p.entryTimes[part] = d
+	}
+}
+
+// programming encodes how zones, partitions and delays are programmed.
+// Must be called with the lock held.
+func (p *Panel) programming() []byte {
+	var buf []byte
+	for _, z := range p.zones {
+		var flags byte
+		if z.Stay {
+			flags |= 0x01
+		}
+		buf = append(buf, byte(z.Partition), byte(z.Function), byte(z.Sensor), flags)
+	}
+	for _, d := range p.entryTimes {
+		buf = append(buf, byte(d/time.Second))
+	}
+	return append(buf, byte(p.exitTime/time.Second))
+}
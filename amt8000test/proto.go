@@ -0,0 +1,151 @@
+package amt8000test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Commands understood by the emulator.
+// These are duplicated from the amt8000 package on purpose: the emulator is
+// an independent implementation of the protocol, so it can catch mistakes in
+// the client.
+const (
+	cmdAuth         = 0xf0f0
+	cmdDisconnect   = 0xf0f1
+	cmdNack         = 0xf0fd
+	cmdStatus       = 0x0b4a
+	cmdSignalLevels = 0x0b4b
+	cmdTime         = 0x0b21
+	cmdSetTime      = 0x4021
+	cmdPanic        = 0x401a
+	cmdArm          = 0x401e
+	cmdTurnOffSiren = 0x4019
+	cmdCleanFiring  = 0x4013
+	cmdBypass       = 0x401f
+	cmdPGM          = 0x4039
+	cmdEventLog     = 0x0b60
+	cmdNames        = 0x0b70
+	cmdUsers        = 0x0b72
+	cmdProgramming  = 0x0b80
+	cmdUser         = 0x4072
+	cmdEvent        = 0xb201
+)
+
+const (
+	subCmdDisarm = 0x00
+	subCmdArm    = 0x01
+	subCmdStay   = 0x02
+)
+
+const allPartitions = 0xff
+
+// reasons the panel gives when refusing a command.
+const (
+	nackInvalidPacket  = 0x00
+	nackInvalidCommand = 0x02
+	nackNotPartitioned = 0x03
+	nackOpenZones      = 0x04
+	nackBypassArmed    = 0x08
+)
+
+type frame struct {
+	dst  int
+	src  int
+	cmd  int
+	data []byte
+}
+
+func (f frame) bytes() []byte {
+	buf := []byte{
+		byte(f.dst >> 8), byte(f.dst),
+		byte(f.src >> 8), byte(f.src),
+		byte((len(f.data) + 2) >> 8), byte(len(f.data) + 2),
+		byte(f.cmd >> 8), byte(f.cmd),
+	}
+	buf = append(buf, f.data...)
+	return append(buf, checksum(buf))
+}
+
+func readFrame(r io.Reader) (frame, error) {
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return frame{}, err
+	}
+	length := int(header[4])<<8 | int(header[5])
+	if length < 2 {
+		return frame{}, fmt.Errorf("invalid length: %d", length)
+	}
+	body := make([]byte, length+1)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return frame{}, err
+	}
+	buf := append(header, body...)
+	if checksum(buf[:len(buf)-1]) != buf[len(buf)-1] {
+		return frame{}, errors.New("bad checksum")
+	}
+	return frame{
+		dst:  int(buf[0])<<8 | int(buf[1]),
+		src:  int(buf[2])<<8 | int(buf[3]),
+		cmd:  int(buf[6])<<8 | int(buf[7]),
+		data: buf[8 : len(buf)-1],
+	}, nil
+}
+
+func checksum(buf []byte) byte {
+	var check byte
+	for _, n := range buf {
+		check ^= n
+	}
+	return check ^ 0xff
+}
+
+// encodeDigits encodes n as Contact ID digits, one per byte, with 0 as 0x0a.
+func encodeDigits(n, size int) []byte {
+	buf := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		digit := byte(n % 10)
+		if digit == 0 {
+			digit = 0x0a
+		}
+		buf[i] = digit
+		n /= 10
+	}
+	return buf
+}
+
+// encodePassword encodes the password the same way the alarm system expects
+// it in the authentication command: one digit per byte, with 0 as 0x0a.
+func encodePassword(pwd string) []byte {
+	var buf []byte
+	for _, r := range pwd {
+		digit := byte(r - '0')
+		if digit == 0 {
+			digit = 0x0a
+		}
+		buf = append(buf, digit)
+	}
+	if len(pwd) == 4 {
+		buf = append([]byte{0x0a, 0x0a}, buf...)
+	}
+	return buf
+}
+
+// decodePassword is the opposite of encodePassword, always returning 6
+// digits.
+func decodePassword(buf []byte) string {
+	pwd := make([]byte, len(buf))
+	for i, digit := range buf {
+		if digit == 0x0a {
+			digit = 0
+		}
+		pwd[i] = '0' + digit
+	}
+	return string(pwd)
+}
+
+func setBit(buf []byte, i int, v bool) {
+	if v {
+		buf[i/8] |= 1 << (i % 8)
+	}
+}
@@ -0,0 +1,147 @@
+package amt8000test
+
+// status encodes the current state as the 143 bytes status reply.
+// Must be called with the lock held.
+func (p *Panel) status() []byte {
+	buf := make([]byte, 143)
+	buf[0] = p.model
+	copy(buf[1:4], p.version[:])
+
+	var enabled, armed, arming int
+	for i, part := range p.partitions {
+		var octet byte
+		if part.Enabled {
+			octet |= 0x80
+			enabled++
+		}
+		if part.Armed {
+			octet |= 0x01
+			armed++
+		}
+		if part.Firing {
+			octet |= 0x04
+		}
+		if part.Fired {
+			octet |= 0x08
+		}
+		if part.Stay {
+			octet |= 0x40
+		}
+		if part.ExitDelay {
+			octet |= 0x10
+			arming++
+		}
+		if part.EntryDelay {
+			octet |= 0x20
+		}
+		buf[21+i] = octet
+	}
+
+	var state byte
+	switch {
+	case arming > 0:
+		state = 0x02
+	case armed == 0:
+		state = 0x00
+	case armed == enabled:
+		state = 0x03
+	default:
+		state = 0x01
+	}
+	buf[20] = state << 5
+	if p.firing() {
+		buf[20] |= 0x08
+	}
+	if p.closed() {
+		buf[20] |= 0x04
+	}
+	if p.siren {
+		buf[20] |= 0x02
+	}
+
+	for i, z := range p.zones {
+		setBit(buf[12:20], i, z.Enabled)
+		setBit(buf[38:46], i, z.Open)
+		setBit(buf[46:54], i, z.Violated)
+		setBit(buf[54:62], i, z.Bypassed)
+		setBit(buf[62:70], i, z.Firing)
+		setBit(buf[72:80], i, z.Fired)
+		setBit(buf[89:97], i, z.Tamper)
+		setBit(buf[105:113], i, z.LowBattery)
+		setBit(buf[80:88], i, z.SupervisionLoss)
+	}
+
+	for i, on := range p.pgms {
+		setBit(buf[113:115], i, on)
+	}
+
+	if p.tamper {
+		buf[71] |= 1 << 0x01
+	}
+	for bit, set := range map[int]bool{
+		0x00: p.troubles.ACLoss,
+		0x04: p.troubles.ClockNotSet,
+		0x05: p.troubles.RFJamming,
+	} {
+		if set {
+			buf[71] |= 1 << bit
+		}
+	}
+	for bit, set := range map[int]bool{
+		0x00: p.troubles.Ethernet,
+		0x01: p.troubles.GPRS,
+		0x02: p.troubles.PhoneLine,
+	} {
+		if set {
+			buf[70] |= 1 << bit
+		}
+	}
+	for _, n := range p.troubles.Sirens {
+		buf[97+n-1] |= 0x01
+	}
+	for _, n := range p.troubles.Repeaters {
+		buf[103+n-1] |= 0x01
+	}
+
+	// battery full
+	buf[134] = 0x04
+
+	if p.statusSize < len(buf) {
+		return buf[:p.statusSize]
+	}
+	return append(buf, make([]byte, p.statusSize-len(buf))...)
+}
+
+func (p *Panel) firing() bool {
+	for _, part := range p.partitions {
+		if part.Firing {
+			return true
+		}
+	}
+	for _, z := range p.zones {
+		if z.Firing {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Panel) closed() bool {
+	for _, z := range p.zones {
+		if z.Enabled && (z.Open || z.Violated) {
+			return false
+		}
+	}
+	return true
+}
+
+// signalLevels encodes the signal level of each device, one per byte: zones,
+// then 2 sirens and 2 repeaters.
+// Must be called with the lock held.
+func (p *Panel) signalLevels() []byte {
+	buf := make([]byte, max(len(p.zones)+2+2, p.levelsSize))
+	for i, z := range p.zones {
+		buf[i] = byte(z.SignalLevel)
+	}
+	return buf[:p.levelsSize]
+}
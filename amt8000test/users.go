@@ -0,0 +1,72 @@
+package amt8000test
+
+// User is an emulated alarm system user.
+type User struct {
+	Enabled    bool
+	Password   string
+	Partitions []int
+}
+
+// SetUser sets the given user, from 1 to 98.
+func (p *Panel) SetUser(n int, u User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[n-1] = u
+}
+
+// User returns the given user, from 1 to 98.
+func (p *Panel) User(n int) User {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.users[n-1]
+}
+
+// Must be called with the lock held.
+func (p *Panel) listUsers(data []byte) ([]byte, error) {
+	if len(data) != 2 {
+		return nil, errInvalidPacket
+	}
+	from, count := int(data[0]), int(data[1])
+	var buf []byte
+	for i := from; i < from+count && i < len(p.users); i++ {
+		u := p.users[i]
+		var flags byte
+		if u.Enabled {
+			flags |= 0x01
+		}
+		var mask int
+		for _, part := range u.Partitions {
+			mask |= 1 << part
+		}
+		buf = append(buf, flags, byte(mask>>8), byte(mask))
+	}
+	return buf, nil
+}
+
+// Must be called with the lock held.
+func (p *Panel) setUser(data []byte) error {
+	if len(data) < 2 || int(data[1]) >= len(p.users) {
+		return errInvalidPacket
+	}
+	n := int(data[1])
+	switch {
+	case data[0] == 0x00 && len(data) == 2:
+		p.users[n].Enabled = false
+	case data[0] == 0x01 && len(data) == 10:
+		mask := int(data[2])<<8 | int(data[3])
+		var partitions []int
+		for part := range p.partitions {
+			if mask&(1<<part) > 0 {
+				partitions = append(partitions, part)
+			}
+		}
+		p.users[n] = User{
+			Enabled:    true,
+			Password:   decodePassword(data[4:10]),
+			Partitions: partitions,
+		}
+	default:
+		return errInvalidPacket
+	}
+	return nil
+}
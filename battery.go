@@ -53,6 +53,9 @@ func batteryStatusFor(resp []byte) BatteryStatus {
 		return BatteryStatusMissing
 	}
 
+	if len(resp) < batteryStatusSize {
+		return BatteryStatusUnknown
+	}
 	batt := resp[134]
 	switch {
 	case batt&0x01 == 0x01:
@@ -1,20 +1,32 @@
 package amt8000
 
 import (
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/caarlos0/sync/cio"
 	logp "github.com/charmbracelet/log"
 	"github.com/j-keck/arping"
 )
 
-var ErrOpenZones = errors.New("failed to arm: open zones")
+var (
+	ErrOpenZones        = errors.New("failed to arm: open zones")
+	ErrNotPermitted     = errors.New("not permitted")
+	ErrInvalidZone      = errors.New("invalid zone")
+	ErrInvalidPartition = errors.New("invalid partition")
+	ErrInvalidPGM       = errors.New("invalid pgm")
+	ErrInvalidUser      = errors.New("invalid user")
+
+	// ErrConnectionClosed happens when using a client whose connection was
+	// closed, either by Close, by the alarm system, or after a request gave
+	// up waiting for its reply.
+	ErrConnectionClosed = errors.New("connection is closed")
+)
 
 var log = logp.NewWithOptions(os.Stderr, logp.Options{
 	ReportTimestamp: true,
@@ -27,12 +39,23 @@ const AllPartitions = 0xff
 const (
 	cmdAuth         = 0xf0f0
 	cmdDisconnect   = 0xf0f1
+	cmdNack         = 0xf0fd // the alarm system refusing a command
 	cmdStatus       = 0x0b4a
+	cmdSignalLevels = 0x0b4b
+	cmdTime         = 0x0b21
+	cmdSetTime      = 0x4021
 	cmdPanic        = 0x401a
 	cmdArm          = 0x401e
 	cmdTurnOffSiren = 0x4019
 	cmdCleanFiring  = 0x4013
 	cmdBypass       = 0x401f
+	cmdPGM          = 0x4039
+	cmdEventLog     = 0x0b60
+	cmdNames        = 0x0b70
+	cmdUsers        = 0x0b72
+	cmdProgramming  = 0x0b80
+	cmdUser         = 0x4072
+	cmdEvent        = 0xb201 // sent by the alarm system on its own
 )
 
 type State byte
@@ -40,7 +63,8 @@ type State byte
 const (
 	StateDisarmed State = 0x00
 	StatePartial  State = 0x01
-	StateArmed    State = 0x03 // one must ask what 0x02 is... and why its missing...
+	StateArming   State = 0x02 // exit delay running, not armed yet
+	StateArmed    State = 0x03
 )
 
 const (
@@ -55,6 +79,8 @@ func (s State) String() string {
 		return "Disarmed"
 	case StatePartial:
 		return "Partial"
+	case StateArming:
+		return "Arming"
 	case StateArmed:
 		return "Armed"
 	default:
@@ -67,15 +93,48 @@ type Client struct {
 	addr    string
 	pass    string
 	timeout time.Duration
+
+	// timezone of the alarm system clock and event log.
+	loc *time.Location
+
+	// called with the events sent by the alarm system, if set.
+	onEvent func(Event)
+
+	// replies read by listen.
+	frames chan Frame
+
+	// closed when listen stops, err tells why.
+	done chan struct{}
+	err  error
 }
 
 func New(host, port, pass string, timeout time.Duration) (*Client, error) {
-	cli := &Client{
+	return NewContext(context.Background(), host, port, pass, timeout)
+}
+
+// NewContext connects and authenticates to the alarm system.
+// The context only applies to the connection and authentication, the client
+// keeps working after it is done.
+func NewContext(ctx context.Context, host, port, pass string, timeout time.Duration) (*Client, error) {
+	cli := newClient(host, port, pass, timeout)
+	return cli, cli.init(ctx)
+}
+
+func newClient(host, port, pass string, timeout time.Duration) *Client {
+	return &Client{
 		addr:    net.JoinHostPort(host, port),
 		pass:    pass,
 		timeout: timeout,
+		loc:     time.Local,
+		frames:  make(chan Frame, 1),
+		done:    make(chan struct{}),
 	}
-	return cli, cli.init()
+}
+
+// SetLocation sets the timezone of the alarm system clock and event log,
+// which is time.Local by default.
+func (c *Client) SetLocation(loc *time.Location) {
+	c.loc = loc
 }
 
 func MacAddress(ip string) (string, error) {
@@ -86,15 +145,70 @@ func MacAddress(ip string) (string, error) {
 	return hw.String(), nil
 }
 
-func (c *Client) Panic() error {
-	payload := makePayload(cmdPanic, []byte{0x02, 0xa5})
-	if _, err := c.conn.Write(payload); err != nil {
-		return fmt.Errorf("could not panic: %w", err)
+// PanicType is the kind of emergency reported by a panic.
+type PanicType byte
+
+const (
+	// PanicSilent reports a panic without sounding the sirens.
+	PanicSilent PanicType = 0x01
+	// PanicAudible reports a panic and sounds the sirens.
+	PanicAudible PanicType = 0x02
+	// PanicFire reports a fire and sounds the sirens.
+	PanicFire PanicType = 0x03
+	// PanicMedical reports a medical emergency without sounding the sirens.
+	PanicMedical PanicType = 0x04
+)
+
+// PanicTypes are all the known panic types.
+var PanicTypes = []PanicType{PanicAudible, PanicSilent, PanicMedical, PanicFire}
+
+func (t PanicType) String() string {
+	switch t {
+	case PanicSilent:
+		return "silent"
+	case PanicAudible:
+		return "audible"
+	case PanicFire:
+		return "fire"
+	case PanicMedical:
+		return "medical"
+	default:
+		return fmt.Sprintf("unknown (%#02x)", byte(t))
+	}
+}
+
+// Audible tells whether the panic sounds the sirens.
+func (t PanicType) Audible() bool {
+	return t == PanicAudible || t == PanicFire
+}
+
+func (c *Client) Panic(kind PanicType) error {
+	return c.PanicContext(context.Background(), kind)
+}
+
+func (c *Client) PanicContext(ctx context.Context, kind PanicType) error {
+	if kind < PanicSilent || kind > PanicMedical {
+		return fmt.Errorf("could not panic: invalid panic type %s", kind)
+	}
+	reply, err := c.request(ctx, cmdPanic, []byte{byte(kind), 0xa5})
+	if err != nil {
+		return fmt.Errorf("could not %s panic: %w", kind, err)
+	}
+	if err := checkReply(cmdPanic, reply); err != nil {
+		return fmt.Errorf("could not %s panic: %w", kind, err)
 	}
 	return nil
 }
 
 func (c *Client) Bypass(zone int, set bool) error {
+	return c.BypassContext(context.Background(), zone, set)
+}
+
+func (c *Client) BypassContext(ctx context.Context, zone int, set bool) error {
+	if zone < 1 || zone > maxZones {
+		return fmt.Errorf("could not set bypass=%v %v: %w", set, zone, ErrInvalidZone)
+	}
+
 	// 0x01 add
 	// 0x00 remove
 	var b byte = 0x00
@@ -102,139 +216,384 @@ func (c *Client) Bypass(zone int, set bool) error {
 		b = 0x01
 	}
 
-	payload := makePayload(cmdBypass, []byte{byte(zone - 1), b})
-	if _, err := c.conn.Write(payload); err != nil {
+	reply, err := c.request(ctx, cmdBypass, []byte{byte(zone - 1), b})
+	if err != nil {
+		return fmt.Errorf("could not set bypass=%v %v: %w", set, zone, err)
+	}
+	if err := checkReply(cmdBypass, reply); err != nil {
 		return fmt.Errorf("could not set bypass=%v %v: %w", set, zone, err)
 	}
 	return nil
 }
 
 func (c *Client) TurnOffSiren(partition byte) error {
+	return c.TurnOffSirenContext(context.Background(), partition)
+}
+
+func (c *Client) TurnOffSirenContext(ctx context.Context, partition byte) error {
 	log.Debug("turn off siren")
-	payload := makePayload(cmdTurnOffSiren, []byte{partition})
-	if _, err := c.conn.Write(payload); err != nil {
+	if !validPartition(partition) {
+		return fmt.Errorf("could not turn siren off %v: %w", partition, ErrInvalidPartition)
+	}
+	reply, err := c.request(ctx, cmdTurnOffSiren, []byte{partition})
+	if err != nil {
+		return fmt.Errorf("could not turn siren off %v: %w", partition, err)
+	}
+	if err := checkReply(cmdTurnOffSiren, reply); err != nil {
 		return fmt.Errorf("could not turn siren off %v: %w", partition, err)
 	}
 	return nil
 }
 
 func (c *Client) CleanFirings() error {
+	return c.CleanFiringsContext(context.Background())
+}
+
+func (c *Client) CleanFiringsContext(ctx context.Context) error {
 	log.Debug("clean firings")
-	payload := makePayload(cmdCleanFiring, nil)
-	if _, err := c.conn.Write(payload); err != nil {
+	reply, err := c.request(ctx, cmdCleanFiring, nil)
+	if err != nil {
+		return fmt.Errorf("could not clean firing: %w", err)
+	}
+	if err := checkReply(cmdCleanFiring, reply); err != nil {
 		return fmt.Errorf("could not clean firing: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) Status() (Status, error) {
-	log.Debug("status")
-	payload := makePayload(cmdStatus, nil)
-	if _, err := c.conn.Write(payload); err != nil {
-		return Status{}, fmt.Errorf("could not write payload: %w", err)
-	}
+	return c.StatusContext(context.Background())
+}
 
-	resp, err := c.limitTimedRead(len(payload))
+func (c *Client) StatusContext(ctx context.Context) (Status, error) {
+	log.Debug("status")
+	reply, err := c.request(ctx, cmdStatus, nil)
 	if err != nil {
-		return Status{}, fmt.Errorf("could not read response: %w", err)
+		return Status{}, fmt.Errorf("could not gather status: %w", err)
 	}
-
-	if len(resp) == 0 {
-		return Status{}, fmt.Errorf("buffer is empty")
+	if reply.isError() {
+		err := UnexpectedCommandError{Want: cmdStatus, Got: reply.Cmd}
+		return Status{}, fmt.Errorf("could not gather status: %w", err)
 	}
-
-	resp2, err := c.limitTimedRead(int(resp[0]))
+	status, err := statusFromBytes(reply.Data)
 	if err != nil {
-		return Status{}, fmt.Errorf("could not gather status: %w", err)
+		return Status{}, err
 	}
-
-	_, reply := parseResponse(append(resp, resp2...))
-	return statusFromBytes(reply)
+	return status, nil
 }
 
 func (c *Client) Disarm(partition byte) error {
+	return c.DisarmContext(context.Background(), partition)
+}
+
+func (c *Client) DisarmContext(ctx context.Context, partition byte) error {
 	log.Debug("disarm", "partition", partition)
-	payload := makePayload(cmdArm, []byte{partition, subCmdDisarm})
-	if _, err := c.conn.Write(payload); err != nil {
+	if !validPartition(partition) {
+		return fmt.Errorf("could not disarm: %w", ErrInvalidPartition)
+	}
+	reply, err := c.request(ctx, cmdArm, []byte{partition, subCmdDisarm})
+	if err != nil {
+		return fmt.Errorf("could not disarm: %w", err)
+	}
+	if err := checkReply(cmdArm, reply); err != nil {
 		return fmt.Errorf("could not disarm: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) Arm(partition byte) error {
+	return c.ArmContext(context.Background(), partition)
+}
+
+func (c *Client) ArmContext(ctx context.Context, partition byte) error {
 	log.Debug("arm", "partition", partition)
-	payload := makePayload(cmdArm, []byte{partition, subCmdArm})
-	if _, err := c.conn.Write(payload); err != nil {
+	return c.arm(ctx, partition, subCmdArm)
+}
+
+// ArmStay arms the given partition using the alarm system stay mode, in which
+// the zones set as stay zones are not armed.
+func (c *Client) ArmStay(partition byte) error {
+	return c.ArmStayContext(context.Background(), partition)
+}
+
+func (c *Client) ArmStayContext(ctx context.Context, partition byte) error {
+	log.Debug("arm stay", "partition", partition)
+	return c.arm(ctx, partition, subCmdStay)
+}
+
+func (c *Client) arm(ctx context.Context, partition, subCmd byte) error {
+	if !validPartition(partition) {
+		return fmt.Errorf("could not arm %v: %w", partition, ErrInvalidPartition)
+	}
+	reply, err := c.request(ctx, cmdArm, []byte{partition, subCmd})
+	if err != nil {
+		return fmt.Errorf("could not arm %v: %w", partition, err)
+	}
+	if err := checkReply(cmdArm, reply); err != nil {
+		if errors.Is(err, ErrOpenZones) {
+			return c.openZonesError(ctx, partition)
+		}
 		return fmt.Errorf("could not arm %v: %w", partition, err)
 	}
+	return nil
+}
 
-	resp, err := c.limitTimedRead(6)
-	if err != nil {
-		return err
+// OpenZonesError happens when the alarm system refuses to arm a partition
+// because some of its zones are open.
+// It wraps ErrOpenZones.
+type OpenZonesError struct {
+	Partition int
+
+	// Zones of the partition which are open or violated, and not bypassed,
+	// from 1 to 64.
+	// If the alarm system can't tell which partitions the zones belong to,
+	// these are the ones of all partitions.
+	Zones []int
+}
+
+func (e OpenZonesError) Error() string {
+	zones := make([]string, 0, len(e.Zones))
+	for _, zone := range e.Zones {
+		zones = append(zones, strconv.Itoa(zone))
 	}
-	resp, err = c.limitTimedRead(int(resp[5]))
+	return fmt.Sprintf(
+		"failed to arm partition %d: open zones: %s",
+		e.Partition, strings.Join(zones, ", "),
+	)
+}
+
+func (e OpenZonesError) Unwrap() error {
+	return RefusedError{Cmd: cmdArm, Reason: reasonOpenZones}
+}
+
+// openZonesError reads the status and programming to tell which zones
+// prevented the partition from being armed, as the refusal does not say it.
+// If they can't be read, the returned error still wraps ErrOpenZones, but
+// isn't a RefusedError, as the connection is no longer in a known state.
+func (c *Client) openZonesError(ctx context.Context, partition byte) error {
+	status, err := c.StatusContext(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("could not arm %v: %w: could not read them: %w", partition, ErrOpenZones, err)
+	}
+	// older firmwares can't tell which partitions the zones belong to.
+	prog, err := c.ProgrammingContext(ctx)
+	programmed := err == nil
+	if !programmed && !errors.As(err, &RefusedError{}) {
+		return fmt.Errorf("could not arm %v: %w: could not read them: %w", partition, ErrOpenZones, err)
 	}
 
-	switch resp[0] {
-	case 0xf0:
-		return ErrOpenZones
-	case 0x40:
-		return nil
-	default:
-		return fmt.Errorf("unknown response:\n%s", hex.Dump(resp))
+	result := OpenZonesError{Partition: int(partition)}
+	for _, zone := range status.Zones {
+		if !zone.Enabled || !zone.IsOpen() || zone.Anulated {
+			continue
+		}
+		if programmed && !prog.inPartition(zone.Number, partition) {
+			continue
+		}
+		result.Zones = append(result.Zones, zone.Number)
 	}
+	return result
+}
+
+// validPartition tells whether the partition is either a single partition
+// or AllPartitions.
+func validPartition(partition byte) bool {
+	return partition < maxPartitions || partition == AllPartitions
 }
 
 func (c *Client) Close() error {
-	if _, err := c.conn.Write(makePayload(cmdDisconnect, nil)); err != nil {
+	defer func() { <-c.done }()
+	if c.broken() {
+		// nothing left to disconnect from.
+		_ = c.conn.Close()
+		return nil
+	}
+	if err := c.write(context.Background(), newFrame(cmdDisconnect, nil)); err != nil {
+		_ = c.conn.Close()
 		return fmt.Errorf("could not disconnect: %w", err)
 	}
 	return c.conn.Close()
 }
 
-func (c *Client) init() error {
-	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+func (c *Client) init(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
 	if err != nil {
+		close(c.done)
 		return fmt.Errorf("could not connect: %w", err)
 	}
 	c.conn = conn
 
-	payload := makeAuthPayload(c.pass)
-	if _, err := c.conn.Write(payload); err != nil {
-		return fmt.Errorf("could not auth: %w", err)
+	if err := c.auth(ctx); err != nil {
+		close(c.done)
+		_ = c.conn.Close()
+		return err
 	}
 
-	resp, err := c.limitTimedRead(10)
-	if err != nil {
+	go c.listen()
+	return nil
+}
+
+func (c *Client) auth(ctx context.Context) error {
+	var reply Frame
+	if err := c.withDeadline(ctx, c.conn.SetDeadline, func() error {
+		if err := WriteFrame(c.conn, newFrame(cmdAuth, makeAuthData(c.pass))); err != nil {
+			return err
+		}
+		var err error
+		reply, err = ReadFrame(c.conn)
+		return err
+	}); err != nil {
 		return fmt.Errorf("could not auth: %w", err)
 	}
+	if err := parseAuthResponse(reply); err != nil {
+		return err
+	}
+	// from now on only listen reads, and it should wait forever.
+	return c.conn.SetDeadline(time.Time{})
+}
 
-	return parseAuthResponse(resp)
+// listen reads all frames coming from the alarm system until the connection
+// is closed, handing events to onEvent and everything else to request.
+func (c *Client) listen() {
+	defer close(c.done)
+	for {
+		frame, err := ReadFrame(c.conn)
+		if err != nil {
+			c.err = err
+			return
+		}
+
+		if frame.Cmd == cmdEvent {
+			c.handleEvent(frame)
+			continue
+		}
+
+		select {
+		case c.frames <- frame:
+		default:
+			log.Warn("discarding unexpected frame", "cmd", fmt.Sprintf("%#04x", frame.Cmd))
+		}
+	}
 }
 
-func (c *Client) limitTimedRead(n int) ([]byte, error) {
-	buf := make([]byte, n)
-	m, err := cio.TimeoutReader(c.conn, c.timeout).Read(buf)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return nil, err
+func (c *Client) handleEvent(frame Frame) {
+	event, err := parseEvent(frame.Data)
+	if err != nil {
+		log.Warn("could not parse event", "err", err)
+		return
 	}
-	if m != n {
-		return nil, fmt.Errorf("wanted %d bytes, read %d", n, m)
+	log.Debug("event", "event", event)
+	if c.onEvent != nil {
+		c.onEvent(event)
 	}
-	return buf, nil
 }
 
-func version(b []byte) string {
-	return fmt.Sprintf("%d.%d.%d", int(b[0]), int(b[1]), int(b[2]))
+// request sends a command and waits for its reply.
+// The reply must either be for the same command, or an error from the alarm
+// system.
+//
+// If it gives up before the reply arrives, the connection is closed, as the
+// late reply would otherwise be taken as the reply of the next request.
+func (c *Client) request(ctx context.Context, cmd int, data []byte) (Frame, error) {
+	if c.broken() {
+		return Frame{}, fmt.Errorf("could not write payload: %w", ErrConnectionClosed)
+	}
+	if err := c.write(ctx, newFrame(cmd, data)); err != nil {
+		// part of the frame might have been written already.
+		c.abort()
+		return Frame{}, fmt.Errorf("could not write payload: %w", err)
+	}
+
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case reply := <-c.frames:
+		if reply.Cmd != cmd && !reply.isError() {
+			return reply, UnexpectedCommandError{Want: cmd, Got: reply.Cmd}
+		}
+		return reply, nil
+	case <-c.done:
+		return Frame{}, fmt.Errorf("could not read response: %w: %w", ErrConnectionClosed, c.err)
+	case <-ctx.Done():
+		c.abort()
+		return Frame{}, ctx.Err()
+	case <-timeout:
+		c.abort()
+		return Frame{}, fmt.Errorf("could not read response: %w", os.ErrDeadlineExceeded)
+	}
+}
+
+// abort closes the connection and waits for listen to stop, so the client
+// is broken and further requests fail right away.
+func (c *Client) abort() {
+	log.Debug("dropping connection after an unfinished request")
+	_ = c.conn.Close()
+	<-c.done
+}
+
+func (c *Client) write(ctx context.Context, frame Frame) error {
+	return c.withDeadline(ctx, c.conn.SetWriteDeadline, func() error {
+		return WriteFrame(c.conn, frame)
+	})
 }
 
-func modelName(b byte) string {
-	switch b {
-	case 0x01:
-		return "AMT-8000"
+// broken tells whether the connection was closed or failed.
+func (c *Client) broken() bool {
+	select {
+	case <-c.done:
+		return true
 	default:
-		return "Unknown"
+		return false
+	}
+}
+
+// withDeadline makes the connection honor both the client timeout and the
+// context deadline and cancellation while fn runs, using the given deadline
+// setter.
+func (c *Client) withDeadline(
+	ctx context.Context,
+	setDeadline func(time.Time) error,
+	fn func() error,
+) error {
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	d, fromCtx := ctx.Deadline()
+	if fromCtx && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	} else {
+		fromCtx = false
+	}
+	if err := setDeadline(deadline); err != nil {
+		return err
 	}
+
+	// unblocks any pending read or write once the context is done.
+	stop := context.AfterFunc(ctx, func() {
+		_ = setDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	if err := fn(); err != nil {
+		if fromCtx && errors.Is(err, os.ErrDeadlineExceeded) {
+			// the connection might notice it slightly before the context.
+			<-ctx.Done()
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
+
+func version(b []byte) string {
+	return fmt.Sprintf("%d.%d.%d", int(b[0]), int(b[1]), int(b[2]))
 }
@@ -1,10 +1,14 @@
 package amt8000
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/caarlos0/homekit-amt8000/contactid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +55,836 @@ func TestMacAddress(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, hw)
 }
+
+func newTestPanel(tb testing.TB) *amt8000test.Panel {
+	tb.Helper()
+	panel := amt8000test.NewPanel("123456")
+	tb.Cleanup(panel.Close)
+	return panel
+}
+
+func newTestClient(tb testing.TB, panel *amt8000test.Panel) *Client {
+	tb.Helper()
+	cli, err := New(panel.Host(), panel.Port(), panel.Password, time.Second)
+	require.NoError(tb, err)
+	tb.Cleanup(func() {
+		_ = cli.Close()
+	})
+	return cli
+}
+
+func TestClient(t *testing.T) {
+	t.Run("status", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(1, amt8000test.Zone{Enabled: true, Open: true})
+		panel.SetZone(2, amt8000test.Zone{Enabled: true, Tamper: true, LowBattery: true, SignalLevel: 7})
+		panel.SetZone(3, amt8000test.Zone{Enabled: true, Violated: true, Bypassed: true})
+		panel.SetZone(64, amt8000test.Zone{Enabled: true, Open: true, Firing: true, Fired: true, SupervisionLoss: true})
+		panel.SetPartition(2, amt8000test.Partition{Enabled: true, Armed: true})
+		panel.SetSiren(true)
+		cli := newTestClient(t, panel)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, "AMT-8000", status.Model)
+		require.Equal(t, "2.5.3", status.Version)
+		require.Equal(t, StatePartial, status.State)
+		require.True(t, status.Siren)
+		require.False(t, status.ZonesClosed)
+		require.Equal(t, BatteryStatusFull, status.Battery)
+		require.Equal(t, Siren{Number: 1}, status.Sirens[0])
+		require.Equal(t, Repeater{Number: 2}, status.Repeaters[1])
+
+		require.Equal(t, Zone{Number: 1, Enabled: true, Open: true}, status.Zones[0])
+		require.Equal(t, Zone{Number: 2, Enabled: true, Tamper: true, LowBattery: true}, status.Zones[1])
+		require.Equal(t, Zone{Number: 3, Enabled: true, Violated: true, Anulated: true}, status.Zones[2])
+		require.Equal(t, Zone{Number: 4}, status.Zones[3])
+		require.Equal(t, Zone{Number: 64, Enabled: true, Open: true, Firing: true, Fired: true, SupervisionLoss: true}, status.Zones[63])
+		require.True(t, status.ZonesFiring)
+
+		require.Equal(t, Partition{Number: 1, Enabled: true}, status.Partitions[1])
+		require.Equal(t, Partition{Number: 2, Enabled: true, Armed: true}, status.Partitions[2])
+
+		require.NoError(t, cli.SignalLevels(&status))
+		require.Equal(t, 7, status.Zones[1].SignalLevel)
+		require.Zero(t, status.Zones[0].SignalLevel)
+	})
+
+	t.Run("models", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(3, amt8000test.Zone{Enabled: true, Open: true})
+		cli := newTestClient(t, panel)
+
+		panel.SetModel(0x7f, 3, 0, 1)
+		panel.SetStatusSize(160)
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, "Unknown (0x7f)", status.Model)
+		require.Equal(t, "3.0.1", status.Version)
+		require.Equal(t, Capabilities{Zones: 64, Partitions: 16, Sirens: 2, Repeaters: 2, PGMs: 16}, status.Capabilities)
+		require.Len(t, status.Zones, 64)
+		require.True(t, status.Zones[2].Open)
+		require.Equal(t, BatteryStatusFull, status.Battery)
+
+		// missing sirens, repeaters and battery.
+		panel.SetTroubles(amt8000test.Troubles{Sirens: []int{1}, Repeaters: []int{2}})
+		panel.SetStatusSize(116)
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, Capabilities{Zones: 64, Partitions: 16, PGMs: 16}, status.Capabilities)
+		require.True(t, status.Zones[2].Open)
+		require.Len(t, status.PGMs, 16)
+		require.Empty(t, status.Sirens)
+		require.Empty(t, status.Repeaters)
+		require.Empty(t, status.Troubles.Supervision.Sirens)
+		require.Empty(t, status.Troubles.Supervision.Repeaters)
+		require.Equal(t, BatteryStatusUnknown, status.Battery)
+
+		// missing PGMs too.
+		panel.SetStatusSize(113)
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Zero(t, status.Capabilities.PGMs)
+		require.Empty(t, status.PGMs)
+
+		// missing zones.
+		panel.SetStatusSize(100)
+		_, err = cli.Status()
+		require.Error(t, err)
+	})
+
+	t.Run("signal levels not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(2, amt8000test.Zone{Enabled: true, SignalLevel: 7})
+		panel.Unsupported(cmdSignalLevels)
+		cli := newTestClient(t, panel)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		var refused RefusedError
+		require.ErrorAs(t, cli.SignalLevels(&status), &refused)
+		require.Zero(t, status.Zones[1].SignalLevel)
+		require.Equal(t, []int{cmdAuth, cmdStatus, cmdSignalLevels}, panel.Commands())
+	})
+
+	t.Run("signal levels ignored", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(2, amt8000test.Zone{Enabled: true, SignalLevel: 7})
+		panel.Ignore(cmdSignalLevels)
+		cli := newTestClient(t, panel)
+
+		// the status is read on its own, so it never waits for them.
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.True(t, status.Zones[1].Enabled)
+		require.Equal(t, []int{cmdAuth, cmdStatus}, panel.Commands())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		require.Error(t, cli.SignalLevelsContext(ctx, &status))
+		require.Zero(t, status.Zones[1].SignalLevel)
+	})
+
+	t.Run("signal levels with another layout", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(2, amt8000test.Zone{Enabled: true, Open: true, SignalLevel: 7})
+		panel.SetSignalLevelsSize(72)
+		cli := newTestClient(t, panel)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Error(t, cli.SignalLevels(&status))
+		require.True(t, status.Zones[1].Open)
+		require.Zero(t, status.Zones[1].SignalLevel)
+	})
+
+	t.Run("troubles", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.False(t, status.Troubles.Any())
+
+		panel.SetZone(10, amt8000test.Zone{Enabled: true, SupervisionLoss: true})
+		panel.SetTroubles(amt8000test.Troubles{
+			ACLoss:    true,
+			GPRS:      true,
+			RFJamming: true,
+			Repeaters: []int{2},
+		})
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, Troubles{
+			ACLoss:    true,
+			GPRS:      true,
+			RFJamming: true,
+			Supervision: Supervision{
+				Zones:     []int{10},
+				Repeaters: []int{2},
+			},
+		}, status.Troubles)
+		require.True(t, status.Troubles.Any())
+		require.True(t, status.Troubles.Communication())
+		require.False(t, status.Tamper)
+		require.Equal(t, BatteryStatusFull, status.Battery)
+	})
+
+	t.Run("arm and disarm", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetPartition(2, amt8000test.Partition{Enabled: true})
+		cli := newTestClient(t, panel)
+
+		require.NoError(t, cli.Arm(1))
+		require.True(t, panel.Partition(1).Armed)
+		require.False(t, panel.Partition(2).Armed)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, StatePartial, status.State)
+
+		require.NoError(t, cli.Arm(AllPartitions))
+		require.True(t, panel.Partition(2).Armed)
+
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, StateArmed, status.State)
+
+		require.NoError(t, cli.Disarm(AllPartitions))
+		require.False(t, panel.Partition(1).Armed)
+		require.False(t, panel.Partition(2).Armed)
+	})
+
+	t.Run("arm stay", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+
+		require.NoError(t, cli.ArmStay(1))
+		require.True(t, panel.Partition(1).Armed)
+		require.True(t, panel.Partition(1).Stay)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.True(t, status.Partitions[1].Stay)
+
+		require.NoError(t, cli.Disarm(1))
+		require.False(t, panel.Partition(1).Stay)
+	})
+
+	t.Run("exit and entry delay", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetExitDelay(true)
+		cli := newTestClient(t, panel)
+
+		require.NoError(t, cli.Arm(1))
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, StateArming, status.State)
+		require.Equal(t, "Arming", status.State.String())
+		require.True(t, status.Partitions[1].ExitDelay)
+		require.False(t, status.Partitions[1].Armed)
+		require.True(t, status.ExitDelay())
+
+		panel.EndExitDelay()
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, StateArmed, status.State)
+		require.False(t, status.ExitDelay())
+		require.False(t, status.EntryDelay())
+
+		panel.SetPartition(1, amt8000test.Partition{Enabled: true, Armed: true, EntryDelay: true})
+		status, err = cli.Status()
+		require.NoError(t, err)
+		require.Equal(t, StateArmed, status.State)
+		require.True(t, status.Partitions[1].EntryDelay)
+		require.True(t, status.EntryDelay())
+
+		require.NoError(t, cli.Disarm(1))
+		require.False(t, panel.Partition(1).EntryDelay)
+	})
+
+	t.Run("open zones", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(5, amt8000test.Zone{Enabled: true, Open: true})
+		cli := newTestClient(t, panel)
+
+		panel.SetZone(6, amt8000test.Zone{Enabled: true, Violated: true})
+		panel.SetZone(7, amt8000test.Zone{Enabled: true, Open: true, Bypassed: true})
+		panel.SetZone(8, amt8000test.Zone{Enabled: true, Open: true, Partition: 2})
+		err := cli.Arm(1)
+		require.ErrorIs(t, err, ErrOpenZones)
+		require.ErrorAs(t, err, &RefusedError{})
+		require.Equal(t, OpenZonesError{Partition: 1, Zones: []int{5, 6}}, err)
+		require.EqualError(t, err, "failed to arm partition 1: open zones: 5, 6")
+		require.False(t, panel.Partition(1).Armed)
+		panel.SetZone(8, amt8000test.Zone{})
+
+		panel.SetZone(6, amt8000test.Zone{Enabled: true})
+
+		require.NoError(t, cli.Bypass(5, true))
+		require.True(t, panel.Zone(5).Bypassed)
+		require.NoError(t, cli.Arm(1))
+		require.True(t, panel.Partition(1).Armed)
+	})
+
+	t.Run("open zones without programming", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(5, amt8000test.Zone{Enabled: true, Open: true, Partition: 1})
+		panel.SetZone(8, amt8000test.Zone{Enabled: true, Open: true, Partition: 2})
+		panel.Unsupported(cmdProgramming)
+		cli := newTestClient(t, panel)
+
+		err := cli.Arm(1)
+		require.Equal(t, OpenZonesError{Partition: 1, Zones: []int{5, 8}}, err)
+	})
+
+	t.Run("open zones unknown", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(5, amt8000test.Zone{Enabled: true, Open: true})
+		panel.Unsupported(cmdStatus)
+		cli := newTestClient(t, panel)
+
+		err := cli.Arm(1)
+		require.ErrorIs(t, err, ErrOpenZones)
+		require.False(t, errors.As(err, &RefusedError{}))
+		require.False(t, isPanelError(err))
+	})
+
+	t.Run("refusals", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(5, amt8000test.Zone{Enabled: true, Partition: 1})
+		cli := newTestClient(t, panel)
+
+		err := cli.Arm(3)
+		require.ErrorIs(t, err, ErrInvalidPartition)
+		require.ErrorAs(t, err, &RefusedError{})
+		require.ErrorIs(t, cli.Disarm(20), ErrInvalidPartition)
+		require.ErrorIs(t, cli.Bypass(0, true), ErrInvalidZone)
+		require.ErrorIs(t, cli.Bypass(65, true), ErrInvalidZone)
+
+		require.NoError(t, cli.Arm(1))
+		require.ErrorIs(t, cli.Bypass(5, true), ErrNotPermitted)
+		require.False(t, panel.Zone(5).Bypassed)
+
+		// the connection is still good after refusals.
+		_, err = cli.Status()
+		require.NoError(t, err)
+	})
+
+	t.Run("panic and siren", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetPartition(1, amt8000test.Partition{Enabled: true, Fired: true})
+		panel.SetZone(2, amt8000test.Zone{Enabled: true, Fired: true})
+		cli := newTestClient(t, panel)
+
+		require.NoError(t, cli.Panic(PanicAudible))
+		require.True(t, panel.Siren())
+		require.NoError(t, cli.TurnOffSiren(AllPartitions))
+		require.False(t, panel.Siren())
+		require.NoError(t, cli.CleanFirings())
+		require.False(t, panel.Partition(1).Fired)
+		require.False(t, panel.Zone(2).Fired)
+	})
+
+	t.Run("panic types", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+
+		for kind, code := range map[PanicType]contactid.Code{
+			PanicSilent:  contactid.CodeSilentPanic,
+			PanicMedical: contactid.CodeMedical,
+		} {
+			require.NoError(t, cli.Panic(kind))
+			require.False(t, panel.Siren(), kind)
+			entries, err := cli.Events(0, 1)
+			require.NoError(t, err)
+			require.Equal(t, code, entries[0].Code, kind)
+		}
+
+		require.NoError(t, cli.Panic(PanicFire))
+		require.True(t, panel.Siren())
+		entries, err := cli.Events(0, 1)
+		require.NoError(t, err)
+		require.Equal(t, contactid.CodeFire, entries[0].Code)
+
+		require.Error(t, cli.Panic(PanicType(0x09)))
+		require.Equal(t, "unknown (0x09)", PanicType(0x09).String())
+	})
+
+	t.Run("pgm", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetPGM(16, true)
+		cli := newTestClient(t, panel)
+
+		require.NoError(t, cli.SetPGM(2, true))
+		require.True(t, panel.PGM(2))
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.Len(t, status.PGMs, 16)
+		require.Equal(t, PGM{Number: 2, On: true}, status.PGMs[1])
+		require.Equal(t, PGM{Number: 16, On: true}, status.PGMs[15])
+		require.False(t, status.PGMs[0].On)
+
+		require.NoError(t, cli.SetPGM(2, false))
+		require.False(t, panel.PGM(2))
+
+		require.NoError(t, cli.PulsePGM(3, time.Millisecond))
+		require.False(t, panel.PGM(3))
+		cmds := panel.Commands()
+		require.Equal(t, []int{0x4039, 0x4039}, cmds[len(cmds)-2:])
+
+		require.ErrorIs(t, cli.SetPGM(0, true), ErrInvalidPGM)
+		require.ErrorIs(t, cli.SetPGM(17, true), ErrInvalidPGM)
+	})
+
+	t.Run("time", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetTroubles(amt8000test.Troubles{ClockNotSet: true})
+		at := time.Date(2024, time.March, 10, 22, 15, 30, 0, time.Local)
+		panel.SetClock(at)
+		cli := newTestClient(t, panel)
+
+		got, err := cli.Time()
+		require.NoError(t, err)
+		require.WithinDuration(t, at, got, 2*time.Second)
+
+		now := time.Now()
+		require.NoError(t, cli.SetTime(now))
+		require.WithinDuration(t, now, panel.Clock(), 2*time.Second)
+
+		status, err := cli.Status()
+		require.NoError(t, err)
+		require.False(t, status.Troubles.ClockNotSet)
+		entries, err := cli.Events(0, 1)
+		require.NoError(t, err)
+		require.Equal(t, contactid.CodeTimeReset, entries[0].Code)
+
+		require.Error(t, cli.SetTime(time.Date(1999, time.December, 31, 0, 0, 0, 0, time.Local)))
+	})
+
+	t.Run("time in another timezone", func(t *testing.T) {
+		// the emulator keeps its clock and event log in the local time, so the
+		// same wall clock is 3 hours later in the alarm system timezone.
+		panel := newTestPanel(t)
+		_, offset := time.Now().Zone()
+		loc := time.FixedZone("panel", offset-3*60*60)
+		at := time.Now()
+		panel.SetClock(at)
+		panel.AddLogEntry(amt8000test.LogEntry{Time: at, Code: 130})
+		cli := newTestClient(t, panel)
+		cli.SetLocation(loc)
+
+		got, err := cli.Time()
+		require.NoError(t, err)
+		require.Equal(t, loc, got.Location())
+		require.WithinDuration(t, at.Add(3*time.Hour), got, 2*time.Second)
+
+		entries, err := cli.Events(0, 1)
+		require.NoError(t, err)
+		require.Equal(t, loc, entries[0].Time.Location())
+		require.WithinDuration(t, at.Add(3*time.Hour), entries[0].Time, 2*time.Second)
+
+		now := time.Now()
+		require.NoError(t, cli.SetTime(now))
+		require.WithinDuration(t, now.Add(-3*time.Hour), panel.Clock(), 2*time.Second)
+	})
+
+	t.Run("time not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.Unsupported(cmdTime)
+		cli := newTestClient(t, panel)
+
+		_, err := cli.Time()
+		require.ErrorAs(t, err, &RefusedError{})
+		require.True(t, isPanelError(err))
+	})
+
+	t.Run("users", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetUser(1, amt8000test.User{Enabled: true, Password: "123456", Partitions: []int{0}})
+		panel.SetUser(3, amt8000test.User{Partitions: []int{1, 15}})
+		cli := newTestClient(t, panel)
+
+		users, err := cli.Users()
+		require.NoError(t, err)
+		require.Len(t, users, 98)
+		require.Equal(t, User{Number: 1, Enabled: true, Partitions: []int{0}}, users[0])
+		require.Equal(t, User{Number: 2, Partitions: []int{}}, users[1])
+		require.Equal(t, User{Number: 3, Partitions: []int{1, 15}}, users[2])
+
+		require.NoError(t, cli.SetUser(98, "654321", []int{1, 2}))
+		require.Equal(t, amt8000test.User{Enabled: true, Password: "654321", Partitions: []int{1, 2}}, panel.User(98))
+
+		require.NoError(t, cli.SetUser(1, "012345", []int{0}))
+		require.Equal(t, "012345", panel.User(1).Password)
+
+		require.NoError(t, cli.DisableUser(1))
+		require.False(t, panel.User(1).Enabled)
+
+		require.ErrorIs(t, cli.SetUser(0, "123456", nil), ErrInvalidUser)
+		require.ErrorIs(t, cli.SetUser(99, "123456", nil), ErrInvalidUser)
+		require.ErrorIs(t, cli.DisableUser(99), ErrInvalidUser)
+		require.ErrorIs(t, cli.SetUser(2, "123456", []int{16}), ErrInvalidPartition)
+		require.Error(t, cli.SetUser(2, "1234", nil))
+		require.Error(t, cli.SetUser(2, "12345", nil))
+		require.Error(t, cli.SetUser(2, "12a456", nil))
+	})
+
+	t.Run("users not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.Unsupported(cmdUsers)
+		cli := newTestClient(t, panel)
+
+		_, err := cli.Users()
+		require.ErrorAs(t, err, &RefusedError{})
+		require.True(t, isPanelError(err))
+	})
+
+	t.Run("programming", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(1, amt8000test.Zone{Enabled: true, Partition: 1, Function: 0x01, Sensor: 0x02})
+		panel.SetZone(64, amt8000test.Zone{Enabled: true, Partition: 2, Function: 0x03, Sensor: 0x01, Stay: true})
+		panel.SetDelays(45*time.Second, map[int]time.Duration{1: 30 * time.Second})
+		cli := newTestClient(t, panel)
+
+		prog, err := cli.Programming()
+		require.NoError(t, err)
+		require.Len(t, prog.Zones, 64)
+		require.Equal(t, ZoneProgramming{
+			Number:    1,
+			Partition: 1,
+			Function:  ZoneFunctionDelayed,
+			Sensor:    SensorContact,
+		}, prog.Zones[0])
+		require.Equal(t, ZoneProgramming{
+			Number:    64,
+			Partition: 2,
+			Function:  ZoneFunctionFollower,
+			Sensor:    SensorMotion,
+			Stay:      true,
+		}, prog.Zones[63])
+		require.Equal(t, ZoneFunctionDisabled, prog.Zones[1].Function)
+		require.Equal(t, 30*time.Second, prog.EntryDelays[1])
+		require.Zero(t, prog.EntryDelays[2])
+		require.Equal(t, 45*time.Second, prog.ExitDelay)
+
+		require.Equal(t, "follower", ZoneFunctionFollower.String())
+		require.Equal(t, "motion", SensorMotion.String())
+	})
+
+	t.Run("programming not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.Unsupported(0x0b80)
+		cli := newTestClient(t, panel)
+
+		_, err := cli.Programming()
+		require.ErrorAs(t, err, &RefusedError{})
+	})
+
+	t.Run("event log", func(t *testing.T) {
+		panel := newTestPanel(t)
+		at := time.Date(2024, time.March, 10, 22, 15, 30, 0, time.Local)
+		panel.AddLogEntry(amt8000test.LogEntry{Time: at, Code: 130, Partition: 1, Zone: 12})
+		panel.AddLogEntry(amt8000test.LogEntry{Time: at, Code: 401, Partition: 1, User: 3})
+		cli := newTestClient(t, panel)
+		require.NoError(t, cli.Arm(1))
+
+		entries, err := cli.Events(0, 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		require.Equal(t, contactid.CodeOpenClose, entries[0].Code)
+		require.True(t, entries[0].Restore)
+		require.Equal(t, LogEntry{Time: at, Code: 401, Partition: 1, User: 3}, entries[1])
+		require.Equal(t, LogEntry{Time: at, Code: 130, Partition: 1, Zone: 12}, entries[2])
+
+		entries, err = cli.Events(2, 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, contactid.CodeBurglary, entries[0].Code)
+
+		entries, err = cli.Events(5, 10)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		_, err = cli.Events(0, 0)
+		require.Error(t, err)
+	})
+
+	t.Run("event log not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.Unsupported(cmdEventLog)
+		cli := newTestClient(t, panel)
+
+		_, err := cli.Events(0, 1)
+		require.ErrorAs(t, err, &RefusedError{})
+		require.True(t, isPanelError(err))
+	})
+
+	t.Run("names", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZoneName(1, "Front door")
+		panel.SetZoneName(64, "Garage")
+		panel.SetPartitionName(2, "Outside")
+		panel.SetPartitionName(0, "Common")
+		panel.SetUserName(3, "Housekeeper")
+		cli := newTestClient(t, panel)
+
+		names, err := cli.Names()
+		require.NoError(t, err)
+		require.Len(t, names.Zones, 64)
+		require.Len(t, names.Partitions, 16)
+		require.Len(t, names.Users, 98)
+		require.Equal(t, "Front door", names.Zone(1))
+		require.Equal(t, "", names.Zone(2))
+		require.Equal(t, "Garage", names.Zone(64))
+		require.Equal(t, "", names.Zone(65))
+		require.Equal(t, "Outside", names.Partition(2))
+		require.Equal(t, "Common", names.Partition(0))
+		require.Equal(t, "", names.Partition(1))
+		require.Equal(t, "", names.Partition(16))
+		require.Equal(t, "Housekeeper", names.User(3))
+	})
+
+	t.Run("names not supported", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.Unsupported(cmdNames)
+		cli := newTestClient(t, panel)
+
+		_, err := cli.Names()
+		require.ErrorAs(t, err, &RefusedError{})
+		require.True(t, isPanelError(err))
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		panel := newTestPanel(t)
+		_, err := New(panel.Host(), panel.Port(), "654321", time.Second)
+		require.ErrorIs(t, err, ErrInvalidPassword)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+		panel.SetDelay(time.Hour)
+
+		cli.timeout = 50 * time.Millisecond
+		_, err := cli.Status()
+		require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+		other := newTestClient(t, panel)
+		panel.SetDelay(time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		_, err := cli.StatusContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, other.ArmContext(ctx, 1), context.DeadlineExceeded)
+	})
+
+	t.Run("late replies", func(t *testing.T) {
+		panel := newTestPanel(t)
+		cli := newTestClient(t, panel)
+		panel.SetDelay(200 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := cli.StatusContext(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// the late status reply must not be taken as the arm reply.
+		require.ErrorIs(t, cli.Arm(1), ErrConnectionClosed)
+		require.False(t, panel.Partition(1).Armed)
+		_, err = cli.Status()
+		require.ErrorIs(t, err, ErrConnectionClosed)
+		require.NoError(t, cli.Close())
+
+		panel.SetDelay(0)
+		cli = newTestClient(t, panel)
+		require.NoError(t, cli.Arm(1))
+		require.True(t, panel.Partition(1).Armed)
+	})
+}
+
+func TestSession(t *testing.T) {
+	t.Run("reuses the connection", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		t.Cleanup(func() { _ = session.Close() })
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, session.Do(func(cli *Client) error {
+				_, err := cli.Status()
+				return err
+			}))
+		}
+		require.Equal(t, 1, panel.Auths())
+	})
+
+	t.Run("location", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		t.Cleanup(func() { _ = session.Close() })
+		loc := time.FixedZone("panel", -3*60*60)
+
+		location := func() *time.Location {
+			var got time.Time
+			require.NoError(t, session.Do(func(cli *Client) (err error) {
+				got, err = cli.Time()
+				return
+			}))
+			return got.Location()
+		}
+
+		session.SetLocation(loc)
+		require.Equal(t, loc, location())
+
+		// also applies to the current connection.
+		session.SetLocation(time.UTC)
+		require.Equal(t, time.UTC, location())
+	})
+
+	t.Run("keeps the connection after refusals", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetZone(1, amt8000test.Zone{Enabled: true, Open: true})
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		t.Cleanup(func() { _ = session.Close() })
+
+		require.ErrorIs(t, session.Do(func(cli *Client) error {
+			return cli.Arm(1)
+		}), ErrOpenZones)
+		require.NoError(t, session.Do(func(cli *Client) error {
+			return cli.Disarm(1)
+		}))
+		require.Equal(t, 1, panel.Auths())
+	})
+
+	t.Run("reconnects", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		t.Cleanup(func() { _ = session.Close() })
+
+		status := func(cli *Client) error {
+			_, err := cli.Status()
+			return err
+		}
+		require.NoError(t, session.Do(status))
+		panel.DropConnections()
+		require.Eventually(t, session.cli.broken, time.Second, 10*time.Millisecond)
+		require.NoError(t, session.Do(status))
+		require.Equal(t, 2, panel.Auths())
+	})
+
+	t.Run("reconnects when idle", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 10*time.Millisecond)
+		t.Cleanup(func() { _ = session.Close() })
+
+		require.NoError(t, session.Do(func(cli *Client) error { return nil }))
+		panel.DropConnections()
+		require.Eventually(t, func() bool {
+			return panel.Auths() == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("reconnects after a timeout", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, 50*time.Millisecond, 0)
+		t.Cleanup(func() { _ = session.Close() })
+
+		panel.SetDelay(200 * time.Millisecond)
+		require.ErrorIs(t, session.Do(func(cli *Client) error {
+			_, err := cli.Status()
+			return err
+		}), os.ErrDeadlineExceeded)
+
+		panel.SetDelay(0)
+		require.NoError(t, session.Do(func(cli *Client) error {
+			return cli.Arm(1)
+		}))
+		require.True(t, panel.Partition(1).Armed)
+		require.Equal(t, 2, panel.Auths())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+
+		require.NoError(t, session.Do(func(cli *Client) error { return nil }))
+		panel.Notify(amt8000test.Event{Account: 1234, Code: 130, Partition: 1, Zone: 5})
+		panel.Notify(amt8000test.Event{Account: 1234, Code: 301, Restore: true})
+
+		event := <-session.Events()
+		require.Equal(t, contactid.Message{
+			Account:   1234,
+			Qualifier: contactid.QualifierNew,
+			Code:      contactid.CodeBurglary,
+			Partition: 1,
+			Zone:      5,
+		}, event.Message)
+		require.NotZero(t, event.Time)
+
+		event = <-session.Events()
+		require.Equal(t, contactid.CodeACLoss, event.Code)
+		require.True(t, event.Restore())
+
+		// events don't get in the way of replies.
+		panel.Notify(amt8000test.Event{Code: 401, Partition: 1, Zone: 10})
+		require.NoError(t, session.Do(func(cli *Client) error {
+			return cli.Arm(1)
+		}))
+		require.Equal(t, contactid.CodeOpenClose, (<-session.Events()).Code)
+
+		require.NoError(t, session.Close())
+		_, ok := <-session.Events()
+		require.False(t, ok)
+	})
+
+	t.Run("keep alive", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 10*time.Millisecond)
+		t.Cleanup(func() { _ = session.Close() })
+
+		require.NoError(t, session.Do(func(cli *Client) error { return nil }))
+		require.Eventually(t, func() bool {
+			return len(panel.Commands()) > 3
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, panel.Auths())
+	})
+
+	t.Run("waiting for the lock", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		t.Cleanup(func() { _ = session.Close() })
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go func() {
+			_ = session.Do(func(cli *Client) error {
+				close(started)
+				<-release
+				return nil
+			})
+		}()
+		<-started
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, session.DoContext(ctx, func(cli *Client) error {
+			return nil
+		}), context.DeadlineExceeded)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		panel := newTestPanel(t)
+		session := NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+		require.NoError(t, session.Close())
+		require.ErrorIs(t, session.Do(func(cli *Client) error { return nil }), ErrSessionClosed)
+	})
+}
@@ -0,0 +1,79 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const timeSize = 6
+
+// Time reads the alarm system clock.
+func (c *Client) Time() (time.Time, error) {
+	return c.TimeContext(context.Background())
+}
+
+func (c *Client) TimeContext(ctx context.Context) (time.Time, error) {
+	log.Debug("time")
+	reply, err := c.request(ctx, cmdTime, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read time: %w", err)
+	}
+	if err := checkReply(cmdTime, reply); err != nil {
+		return time.Time{}, fmt.Errorf("could not read time: %w", err)
+	}
+	return timeFromBytes(reply.Data, c.loc)
+}
+
+// SetTime sets the alarm system clock, to the second.
+func (c *Client) SetTime(t time.Time) error {
+	return c.SetTimeContext(context.Background(), t)
+}
+
+func (c *Client) SetTimeContext(ctx context.Context, t time.Time) error {
+	log.Debug("set time", "time", t)
+	data, err := timeToBytes(t, c.loc)
+	if err != nil {
+		return fmt.Errorf("could not set time: %w", err)
+	}
+	reply, err := c.request(ctx, cmdSetTime, data)
+	if err != nil {
+		return fmt.Errorf("could not set time: %w", err)
+	}
+	if err := checkReply(cmdSetTime, reply); err != nil {
+		return fmt.Errorf("could not set time: %w", err)
+	}
+	return nil
+}
+
+// timeFromBytes decodes the time as in the event log:
+//
+//	day | month | year | hour | minute | second
+//
+// The year is relative to 2000, and the time is in the alarm system
+// timezone, loc.
+func timeFromBytes(resp []byte, loc *time.Location) (time.Time, error) {
+	if len(resp) != timeSize {
+		return time.Time{}, fmt.Errorf("invalid time size: %d", len(resp))
+	}
+	return time.Date(
+		2000+int(resp[2]), time.Month(resp[1]), int(resp[0]),
+		int(resp[3]), int(resp[4]), int(resp[5]), 0,
+		loc,
+	), nil
+}
+
+func timeToBytes(t time.Time, loc *time.Location) ([]byte, error) {
+	t = t.In(loc)
+	if t.Year() < 2000 || t.Year() > 2255 {
+		return nil, fmt.Errorf("invalid year: %d", t.Year())
+	}
+	return []byte{
+		byte(t.Day()),
+		byte(t.Month()),
+		byte(t.Year() - 2000),
+		byte(t.Hour()),
+		byte(t.Minute()),
+		byte(t.Second()),
+	}, nil
+}
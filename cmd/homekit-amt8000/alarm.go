@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/brutella/hap"
@@ -9,6 +13,7 @@ import (
 	"github.com/brutella/hap/characteristic"
 	"github.com/brutella/hap/service"
 	client "github.com/caarlos0/homekit-amt8000"
+	"golang.org/x/exp/slices"
 )
 
 type SecuritySystem struct {
@@ -20,6 +25,10 @@ type SecuritySystem struct {
 
 	cfg     Config
 	execute Executor
+
+	// zones that prevented the last arming attempt.
+	mu       sync.Mutex
+	blocking []int
 }
 
 func NewSecuritySystem(info accessory.Info, cfg Config, execute Executor) *SecuritySystem {
@@ -43,31 +52,60 @@ func NewSecuritySystem(info accessory.Info, cfg Config, execute Executor) *Secur
 
 	a.SecuritySystem.SecuritySystemTargetState.SetValueRequestFunc = a.updateHandler
 
+	// starts showing an empty status, so initialize can set it up: disarmed,
+	// not tampered, and with an unknown battery.
+	_ = a.SecuritySystem.SecuritySystemCurrentState.SetValue(
+		characteristic.SecuritySystemCurrentStateDisarmed,
+	)
+	_ = a.LowBattery.SetValue(characteristic.StatusLowBatteryBatteryLevelLow)
+	armStateGauge.Set(float64(characteristic.SecuritySystemCurrentStateDisarmed))
+	tamperGauge.WithLabelValues("system").Set(0)
+
 	return a
 }
 
-func (a *SecuritySystem) Update(status client.Status) {
-	armStateGauge.Set(float64(a.cfg.getAlarmState(status)))
-	tamperGauge.WithLabelValues("system").Set(boolAs[float64](status.Tamper))
-	if v := a.cfg.getAlarmState(status); a.SecuritySystem.SecuritySystemCurrentState.Value() != v {
-		err := a.SecuritySystem.SecuritySystemCurrentState.SetValue(v)
-		log.Info("set current state", "state", v, "err", err)
-	}
-
-	if v := boolAs[int](status.Tamper); a.Tampered.Value() != v {
-		_ = a.Tampered.SetValue(boolAs[int](status.Tamper))
-		if status.Tamper {
+// Handle shows the state, tamper and battery changes of the alarm system.
+func (a *SecuritySystem) Handle(change client.Change, status client.Status) {
+	switch change.Kind {
+	case client.ChangeState,
+		client.ChangeSiren,
+		client.ChangePartitionArmed,
+		client.ChangePartitionStay,
+		client.ChangePartitionFiring,
+		client.ChangePartitionExitDelay:
+		a.updateState(status)
+	case client.ChangeTamper:
+		tamperGauge.WithLabelValues("system").Set(boolAs[float64](change.Set))
+		_ = a.Tampered.SetValue(boolAs[int](change.Set))
+		if change.Set {
 			log.Warn("alarm tampered")
 		}
+	case client.ChangeBattery:
+		a.updateBattery(change.BatteryTo)
+	}
+}
+
+func (a *SecuritySystem) updateState(status client.Status) {
+	v := a.cfg.getAlarmState(status)
+	if v < 0 {
+		log.Debug("keeping current state", "state", status.State, "exit delay", status.ExitDelay())
+		return
 	}
+	armStateGauge.Set(float64(v))
+	if a.SecuritySystem.SecuritySystemCurrentState.Value() != v {
+		err := a.SecuritySystem.SecuritySystemCurrentState.SetValue(v)
+		log.Info("set current state", "state", v, "err", err)
+	}
+}
 
+func (a *SecuritySystem) updateBattery(battery client.BatteryStatus) {
 	// shows unknown, missing, short-circuit, and dead as a dead battery.
-	if v := boolAs[int](status.Battery <= client.BatteryStatusDead); a.LowBattery.Value() != v {
+	if v := boolAs[int](battery <= client.BatteryStatusDead); a.LowBattery.Value() != v {
 		_ = a.LowBattery.SetValue(v)
 		log.Warn("alarm battery is dead or with low voltage")
 	}
 
-	if v := status.Battery.Level(); a.BatteryLevel.Value() != v {
+	if v := battery.Level(); a.BatteryLevel.Value() != v {
 		_ = a.BatteryLevel.SetValue(v)
 		log.Infof("alarm battery level is %v", v)
 	}
@@ -75,8 +113,10 @@ func (a *SecuritySystem) Update(status client.Status) {
 
 func (a *SecuritySystem) updateHandler(
 	v interface{},
-	_ *http.Request,
+	r *http.Request,
 ) (response interface{}, code int) {
+	ctx := requestContext(r)
+
 	// If we fail to arm, it might be that some partition succeeded arming,
 	// while another didn't...
 	// To prevent weird states, we disarm the alarm again if any partition
@@ -90,46 +130,33 @@ func (a *SecuritySystem) updateHandler(
 
 	// Disarm the alarm before any state changes.
 	// This allows to properly change between armed states.
-	if err := a.execute(func(cli *client.Client) error {
-		return cli.Disarm(client.AllPartitions)
+	if err := a.execute(ctx, func(cli *client.Client) error {
+		return cli.DisarmContext(ctx, client.AllPartitions)
 	}); err != nil {
 		log.Error("could not disarm", "err", err)
-		return nil, hap.JsonStatusInvalidValueInRequest
+		return nil, hapStatus(err)
 	}
 
+	fail := func(err error) (interface{}, int) {
+		log.Error("could not arm", "err", err)
+		disarm()
+		a.setBlocking(err)
+		return nil, hapStatus(err)
+	}
+
+	a.setBlocking(nil)
 	switch v.(int) {
 	case characteristic.SecuritySystemTargetStateStayArm:
-		for _, part := range a.cfg.StayPartitions {
-			log.Info("arm stay", "partition", part)
-			if err := a.execute(func(cli *client.Client) error {
-				return cli.Arm(toPartition(part))
-			}); err != nil {
-				log.Error("could not arm", "err", err)
-				disarm()
-				return nil, hap.JsonStatusResourceBusy
-			}
+		if err := a.arm(ctx, "stay", a.cfg.StayPartitions, a.cfg.StayNative); err != nil {
+			return fail(err)
 		}
 	case characteristic.SecuritySystemTargetStateAwayArm:
-		for _, part := range a.cfg.AwayPartitions {
-			log.Info("arm away", "partition", part)
-			if err := a.execute(func(cli *client.Client) error {
-				return cli.Arm(toPartition(part))
-			}); err != nil {
-				log.Error("could not arm partition 2", "err", err)
-				disarm()
-				return nil, hap.JsonStatusResourceBusy
-			}
+		if err := a.arm(ctx, "away", a.cfg.AwayPartitions, a.cfg.AwayNative); err != nil {
+			return fail(err)
 		}
 	case characteristic.SecuritySystemTargetStateNightArm:
-		for _, part := range a.cfg.NightPartitions {
-			log.Info("arm night", "partition", part)
-			if err := a.execute(func(cli *client.Client) error {
-				return cli.Arm(toPartition(part))
-			}); err != nil {
-				log.Error("could not arm partition 2", "err", err)
-				disarm()
-				return nil, hap.JsonStatusResourceBusy
-			}
+		if err := a.arm(ctx, "night", a.cfg.NightPartitions, a.cfg.NightNative); err != nil {
+			return fail(err)
 		}
 	case characteristic.SecuritySystemTargetStateDisarm:
 		log.Info("disarm")
@@ -139,7 +166,7 @@ func (a *SecuritySystem) updateHandler(
 		go func() {
 			time.Sleep(a.cfg.CleanFiringsAfter)
 			log.Info("cleaning firings")
-			if err := a.execute(func(cli *client.Client) error {
+			if err := a.execute(context.Background(), func(cli *client.Client) error {
 				return cli.CleanFirings()
 			}); err != nil {
 				log.Error("could not clean firings", "err", err)
@@ -151,6 +178,58 @@ func (a *SecuritySystem) updateHandler(
 	return nil, hap.JsonStatusSuccess
 }
 
+// arm arms the given partitions, using the alarm system own stay mode for the
+// ones also in native.
+func (a *SecuritySystem) arm(ctx context.Context, mode string, partitions, native []int) error {
+	for _, part := range partitions {
+		stay := slices.Contains(native, part)
+		log.Info("arm "+mode, "partition", part, "native stay", stay)
+		if err := a.execute(ctx, func(cli *client.Client) error {
+			if stay {
+				return cli.ArmStayContext(ctx, toPartition(part))
+			}
+			return cli.ArmContext(ctx, toPartition(part))
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBlocking keeps the zones that prevented arming, if err says so, or clears
+// them otherwise.
+func (a *SecuritySystem) setBlocking(err error) {
+	var openZones client.OpenZonesError
+	_ = errors.As(err, &openZones)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.blocking = openZones.Zones
+
+	blockingGauge.Reset()
+	if len(openZones.Zones) == 0 {
+		return
+	}
+	names := make([]string, 0, len(openZones.Zones))
+	for _, zone := range openZones.Zones {
+		name := a.cfg.zoneName(zone)
+		names = append(names, name)
+		blockingGauge.WithLabelValues(name).Set(1)
+	}
+	log.Warn(
+		"open zones prevent arming",
+		"partition", openZones.Partition,
+		"zones", strings.Join(names, ", "),
+	)
+}
+
+// Blocking returns the zones that prevented the last arming attempt.
+func (a *SecuritySystem) Blocking() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.blocking
+}
+
 func toPartition(i int) byte {
 	if i == 0 {
 		return client.AllPartitions
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExecutor(tb testing.TB, panel *amt8000test.Panel) Executor {
+	tb.Helper()
+	session := client.NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+	tb.Cleanup(func() { _ = session.Close() })
+	return newExecutor(session)
+}
+
+func testStatus(tb testing.TB, execute Executor) client.Status {
+	tb.Helper()
+	var status client.Status
+	require.NoError(tb, execute(context.Background(), func(cli *client.Client) (err error) {
+		status, err = cli.Status()
+		return
+	}))
+	return status
+}
+
+func TestSecuritySystem(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	for i := 1; i <= 3; i++ {
+		panel.SetPartition(i, amt8000test.Partition{Enabled: true})
+	}
+
+	cfg := Config{
+		StayPartitions:  []int{1},
+		NightPartitions: []int{2, 3},
+		AwayPartitions:  []int{0},
+	}
+	execute := newTestExecutor(t, panel)
+	alarm := NewSecuritySystem(accessory.Info{Name: "Alarm"}, cfg, execute)
+	update := updater(alarm, client.Status{})
+
+	armed := func() []bool {
+		return []bool{
+			panel.Partition(1).Armed,
+			panel.Partition(2).Armed,
+			panel.Partition(3).Armed,
+		}
+	}
+
+	t.Run("stay", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateStayArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{true, false, false}, armed())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateStayArm,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+
+	t.Run("night", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateNightArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{false, true, true}, armed())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateNightArm,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+
+	t.Run("away", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateAwayArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{true, true, true}, armed())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateAwayArm,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+
+	t.Run("disarm", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateDisarm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{false, false, false}, armed())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateDisarmed,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+
+	t.Run("open zones", func(t *testing.T) {
+		panel.SetZone(1, amt8000test.Zone{Enabled: true, Open: true, Partition: 3})
+		t.Cleanup(func() { panel.SetZone(1, amt8000test.Zone{}) })
+
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateNightArm, nil)
+		require.Equal(t, hap.JsonStatusResourceBusy, code)
+		require.Equal(t, []bool{false, false, false}, armed())
+		require.Equal(
+			t,
+			characteristic.SecuritySystemTargetStateDisarm,
+			alarm.SecuritySystem.SecuritySystemTargetState.Value(),
+		)
+		require.Equal(t, []int{1}, alarm.Blocking())
+
+		panel.SetZone(1, amt8000test.Zone{Enabled: true, Partition: 3})
+		_, code = alarm.updateHandler(characteristic.SecuritySystemTargetStateNightArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Empty(t, alarm.Blocking())
+	})
+}
+
+func TestSecuritySystemNativeStay(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	for i := 1; i <= 3; i++ {
+		panel.SetPartition(i, amt8000test.Partition{Enabled: true})
+	}
+
+	cfg := Config{
+		StayPartitions:  []int{1, 2},
+		StayNative:      []int{2},
+		NightPartitions: []int{1, 2},
+		AwayPartitions:  []int{0},
+	}
+	execute := newTestExecutor(t, panel)
+	alarm := NewSecuritySystem(accessory.Info{Name: "Alarm"}, cfg, execute)
+	update := updater(alarm, client.Status{})
+
+	stay := func() []bool {
+		return []bool{
+			panel.Partition(1).Stay,
+			panel.Partition(2).Stay,
+		}
+	}
+
+	t.Run("stay", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateStayArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{false, true}, stay())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateStayArm,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+
+	t.Run("night", func(t *testing.T) {
+		_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateNightArm, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.Equal(t, []bool{false, false}, stay())
+
+		update(testStatus(t, execute))
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateNightArm,
+			alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+		)
+	})
+}
+
+func TestSecuritySystemExitDelay(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetExitDelay(true)
+
+	cfg := Config{
+		StayPartitions:   []int{1},
+		NightPartitions:  []int{1},
+		AwayPartitions:   []int{0},
+		EntryDelaySensor: true,
+	}
+	execute := newTestExecutor(t, panel)
+	alarm := NewSecuritySystem(accessory.Info{Name: "Alarm"}, cfg, execute)
+	update := updater(alarm, client.Status{})
+	update(testStatus(t, execute))
+	require.Equal(
+		t,
+		characteristic.SecuritySystemCurrentStateDisarmed,
+		alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+	)
+
+	_, code := alarm.updateHandler(characteristic.SecuritySystemTargetStateAwayArm, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+
+	update(testStatus(t, execute))
+	require.Equal(
+		t,
+		characteristic.SecuritySystemCurrentStateDisarmed,
+		alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+	)
+
+	panel.EndExitDelay()
+	update(testStatus(t, execute))
+	require.Equal(
+		t,
+		characteristic.SecuritySystemCurrentStateAwayArm,
+		alarm.SecuritySystem.SecuritySystemCurrentState.Value(),
+	)
+
+	status := testStatus(t, execute)
+	sensors := setupEntryDelay(cfg, status)
+	require.Len(t, sensors, 1)
+	require.Equal(t, characteristic.ContactSensorStateContactDetected, sensors[0].EntryDelay.ContactSensorState.Value())
+
+	panel.SetPartition(1, amt8000test.Partition{Enabled: true, Armed: true, EntryDelay: true})
+	updater(sensors[0], status)(testStatus(t, execute))
+	require.Equal(t, characteristic.ContactSensorStateContactNotDetected, sensors[0].EntryDelay.ContactSensorState.Value())
+}
@@ -0,0 +1,32 @@
+package main
+
+import client "github.com/caarlos0/homekit-amt8000"
+
+// changeHandler shows what changed in the alarm system status, e.g. in an
+// accessory or in the metrics.
+type changeHandler interface {
+	Handle(change client.Change, status client.Status)
+}
+
+// changeHandlerFunc allows the use of ordinary functions as change handlers.
+type changeHandlerFunc func(change client.Change, status client.Status)
+
+func (fn changeHandlerFunc) Handle(change client.Change, status client.Status) {
+	fn(change, status)
+}
+
+// initialize shows the given status in a handler that is showing an empty
+// one, as everything that is set is a change from an empty status.
+func initialize(h changeHandler, status client.Status) {
+	for _, change := range status.Diff(client.Status{}) {
+		h.Handle(change, status)
+	}
+}
+
+// appendHandlers appends the given accessories to the change handlers.
+func appendHandlers[T changeHandler](handlers []changeHandler, accessories []T) []changeHandler {
+	for _, a := range accessories {
+		handlers = append(handlers, a)
+	}
+	return handlers
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// updater returns a function that shows each new status in h, which is
+// showing prev, as the bridge does.
+func updater(h changeHandler, prev client.Status) func(status client.Status) {
+	return func(status client.Status) {
+		for _, change := range status.Diff(prev) {
+			h.Handle(change, status)
+		}
+		prev = status
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	var changes []client.Change
+	h := changeHandlerFunc(func(change client.Change, _ client.Status) {
+		changes = append(changes, change)
+	})
+
+	initialize(h, client.Status{})
+	require.Empty(t, changes)
+
+	initialize(h, client.Status{Siren: true, PGMs: []client.PGM{{Number: 1, On: true}}})
+	require.Equal(t, []client.Change{
+		{Kind: client.ChangeSiren, Set: true},
+		{Kind: client.ChangePGM, Number: 1, Set: true},
+	}, changes)
+}
+
+func TestTroubleGauges(t *testing.T) {
+	status := client.Status{
+		Troubles: client.Troubles{
+			ACLoss:      true,
+			Supervision: client.Supervision{Zones: []int{2}},
+		},
+		Zones: []client.Zone{{Number: 1}, {Number: 2, SupervisionLoss: true}},
+	}
+	update := updater(setupTroubleGauges(status), status)
+	require.Equal(t, 1.0, testutil.ToFloat64(troubleGauge.WithLabelValues("ac_loss")))
+	require.Equal(t, 0.0, testutil.ToFloat64(troubleGauge.WithLabelValues("gprs")))
+	require.Equal(t, 1.0, testutil.ToFloat64(supervisionLostGauge))
+
+	update(client.Status{
+		Troubles: client.Troubles{GPRS: true},
+		Zones:    []client.Zone{{Number: 1}, {Number: 2}},
+	})
+	require.Equal(t, 0.0, testutil.ToFloat64(troubleGauge.WithLabelValues("ac_loss")))
+	require.Equal(t, 1.0, testutil.ToFloat64(troubleGauge.WithLabelValues("gprs")))
+	require.Equal(t, 0.0, testutil.ToFloat64(supervisionLostGauge))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// how frequently the alarm system clock is synced.
+const clockInterval = 24 * time.Hour
+
+// syncClock reads the alarm system clock, reporting how far it is from the
+// host clock, and sets it to the host time.
+func syncClock(ctx context.Context, execute Executor) error {
+	return execute(ctx, func(cli *client.Client) error {
+		t, err := cli.TimeContext(ctx)
+		if err != nil {
+			return err
+		}
+		drift := t.Sub(time.Now())
+		clockDriftGauge.Set(drift.Seconds())
+		log.Info("alarm system clock", "time", t, "drift", drift.Round(time.Second))
+		if err := cli.SetTimeContext(ctx, time.Now()); err != nil {
+			return err
+		}
+		clockDriftGauge.Set(0)
+		log.Info("alarm system clock synced")
+		return nil
+	})
+}
+
+// watchClock syncs the clock right away, and then daily or whenever asked to
+// through now, until ctx is done.
+func watchClock(ctx context.Context, execute Executor, now <-chan struct{}) {
+	tick := time.NewTicker(clockInterval)
+	defer tick.Stop()
+	for {
+		if err := syncClock(ctx, execute); err != nil {
+			log.Error("could not sync the alarm system clock", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		case <-now:
+		}
+	}
+}
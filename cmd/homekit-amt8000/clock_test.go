@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncClock(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	at := time.Now().Add(-time.Hour)
+	panel.SetClock(at)
+
+	execute := newTestExecutor(t, panel)
+
+	require.NoError(t, syncClock(context.Background(), execute))
+	require.WithinDuration(t, time.Now(), panel.Clock(), 2*time.Second)
+}
+
+func TestSyncClockNotSupported(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.Unsupported(0x0b21)
+
+	execute := newTestExecutor(t, panel)
+
+	// refused right away, without logging in again nor retrying.
+	require.Error(t, syncClock(context.Background(), execute))
+	require.Equal(t, 1, panel.Auths())
+}
+
+func TestTimezoneConfig(t *testing.T) {
+	vars := map[string]string{
+		"HOST":     "localhost",
+		"PASSWORD": "123456",
+		"AWAY":     "0",
+		"STAY":     "1",
+		"NIGHT":    "1",
+	}
+	cfg, err := env.ParseAsWithOptions[Config](env.Options{Environment: vars})
+	require.NoError(t, err)
+	require.Nil(t, cfg.Timezone)
+
+	vars["TIMEZONE"] = "America/Sao_Paulo"
+	cfg, err = env.ParseAsWithOptions[Config](env.Options{Environment: vars})
+	require.NoError(t, err)
+	require.Equal(t, "America/Sao_Paulo", cfg.Timezone.String())
+
+	vars["TIMEZONE"] = "Nowhere/Nope"
+	_, err = env.ParseAsWithOptions[Config](env.Options{Environment: vars})
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// commandConfig is what the subcommands need, so they can run without the
+// whole bridge configuration.
+type commandConfig struct {
+	Host          string        `env:"HOST,notEmpty"`
+	Port          string        `env:"PORT"           envDefault:"9009"`
+	Password      string        `env:"PASSWORD,notEmpty"`
+	ClientTimeout time.Duration `env:"CLIENT_TIMEOUT" envDefault:"10s"`
+}
+
+type command func(ctx context.Context, args []string, w io.Writer) error
+
+var commands = map[string]command{
+	"discover": discoverCommand,
+	"users": func(ctx context.Context, args []string, w io.Writer) error {
+		return withClient(ctx, func(cli *client.Client) error {
+			return usersCommand(ctx, cli, args, os.Stdin, w)
+		})
+	},
+}
+
+// runCommand runs the subcommand named by the first argument.
+func runCommand(ctx context.Context, args []string, w io.Writer) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, available: %s", args[0], strings.Join(names, ", "))
+	}
+	return cmd(ctx, args[1:], w)
+}
+
+// withClient connects to the alarm system configured in the environment.
+func withClient(ctx context.Context, fn func(cli *client.Client) error) error {
+	var cfg commandConfig
+	if err := env.Parse(&cfg); err != nil {
+		return err
+	}
+	cli, err := client.NewContext(ctx, cfg.Host, cfg.Port, cfg.Password, cfg.ClientTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cli.Close() }()
+	return fn(cli)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,14 +17,29 @@ type Config struct {
 	Password          string        `env:"PASSWORD,notEmpty"`
 	MotionZones       []int         `env:"MOTION"`
 	ContactZones      []int         `env:"CONTACT"`
+	AutoZones         bool          `env:"AUTO_ZONES"`
+	AutoZonesKind     string        `env:"AUTO_ZONES_KIND"     envDefault:"contact"`
 	BypassZones       []int         `env:"BYPASS"`
 	AwayPartitions    []int         `env:"AWAY,notEmpty"`
 	StayPartitions    []int         `env:"STAY,notEmpty"`
 	NightPartitions   []int         `env:"NIGHT,notEmpty"`
+	AwayNative        []int         `env:"AWAY_NATIVE"`
+	StayNative        []int         `env:"STAY_NATIVE"`
+	NightNative       []int         `env:"NIGHT_NATIVE"`
 	ZoneNames         []string      `env:"ZONE_NAMES"`
+	PanelNames        bool          `env:"PANEL_NAMES"`
 	Sirens            []int         `env:"SIRENS"`
 	Repeaters         []int         `env:"REPEATERS"`
+	PartitionSensors  []int         `env:"PARTITION_SENSORS"`
+	TroubleSensors    bool          `env:"TROUBLE_SENSORS"`
+	EntryDelaySensor  bool          `env:"ENTRY_DELAY_SENSOR"`
+	AlarmMemory       bool          `env:"ALARM_MEMORY"`
 	CleanFiringsAfter time.Duration `env:"CLEAN_FIRINGS_AFTER"`
+	OpenZonesFault    bool          `env:"OPEN_ZONES_FAULT"`
+	PanicTypes        []string      `env:"PANIC"               envDefault:"audible"`
+	PGMs              []string      `env:"PGMS"`
+	PGMPulse          time.Duration `env:"PGM_PULSE"           envDefault:"1s"`
+	SyncTime          bool          `env:"SYNC_TIME"`
 	Address           string        `env:"LISTEN" envDefault:":9009"`
 
 	// how frequently should we ping the system to gather its status
@@ -32,6 +48,13 @@ type Config struct {
 	// timeout to connect... probably a good idea to keep it lower/equal to
 	// StatusInterval
 	ClientTimeout time.Duration `env:"CLIENT_TIMEOUT" envDefault:"10s"`
+
+	// how frequently should we ping the system to keep the connection alive
+	// when idle
+	KeepAliveInterval time.Duration `env:"KEEPALIVE_INTERVAL" envDefault:"30s"`
+
+	// timezone of the alarm system clock, defaults to the host one
+	Timezone *time.Location `env:"TIMEZONE"`
 }
 
 type zoneKind uint8
@@ -55,6 +78,9 @@ type zoneConfig struct {
 	name        string
 	kind        zoneKind
 	allowBypass bool
+
+	// whether to show a fault when the zone prevents arming.
+	showFault bool
 }
 
 func (c Config) zoneName(n int) string {
@@ -88,6 +114,7 @@ func (c Config) allZones() []zoneConfig {
 			name:        c.zoneName(z),
 			kind:        kindMotion,
 			allowBypass: slices.Contains(c.BypassZones, z),
+			showFault:   c.OpenZonesFault,
 		})
 	}
 	for _, z := range c.ContactZones {
@@ -96,6 +123,7 @@ func (c Config) allZones() []zoneConfig {
 			name:        c.zoneName(z),
 			kind:        kindContact,
 			allowBypass: slices.Contains(c.BypassZones, z),
+			showFault:   c.OpenZonesFault,
 		})
 	}
 	slices.SortFunc(zones, func(a, b zoneConfig) int {
@@ -107,10 +135,140 @@ func (c Config) allZones() []zoneConfig {
 	return zones
 }
 
+// withAutoZones adds the enabled zones not in MotionZones nor ContactZones,
+// as motion or contact sensors depending on how they are programmed, falling
+// back to AutoZonesKind when the programming is unknown.
+// Accessories are keyed by zone number, so their ids don't change as zones
+// are added or removed.
+func (c Config) withAutoZones(status client.Status, prog client.Programming) Config {
+	if !c.AutoZones {
+		return c
+	}
+	motion := c.AutoZonesKind == "motion"
+	if !motion && c.AutoZonesKind != "contact" {
+		log.Warn("invalid auto zones kind, using contact", "kind", c.AutoZonesKind)
+	}
+
+	c.MotionZones = slices.Clone(c.MotionZones)
+	c.ContactZones = slices.Clone(c.ContactZones)
+	for _, zone := range status.Zones {
+		if !zone.Enabled ||
+			slices.Contains(c.MotionZones, zone.Number) ||
+			slices.Contains(c.ContactZones, zone.Number) {
+			continue
+		}
+		sensor := client.SensorUnknown
+		if zone.Number <= len(prog.Zones) {
+			sensor = prog.Zones[zone.Number-1].Sensor
+		}
+		switch {
+		case sensor == client.SensorButton:
+			log.Debug("not adding remote control or panic button zone", "zone", zone.Number)
+		case sensor == client.SensorMotion,
+			sensor != client.SensorContact && motion:
+			c.MotionZones = append(c.MotionZones, zone.Number)
+		default:
+			c.ContactZones = append(c.ContactZones, zone.Number)
+		}
+	}
+	return c
+}
+
+// panicTypes returns the panic types to create switches for, ignoring unknown
+// ones.
+func (c Config) panicTypes() []client.PanicType {
+	var result []client.PanicType
+	for _, name := range c.PanicTypes {
+		i := slices.IndexFunc(client.PanicTypes, func(kind client.PanicType) bool {
+			return kind.String() == strings.ToLower(strings.TrimSpace(name))
+		})
+		if i < 0 {
+			log.Warn("unknown panic type, ignoring it", "type", name)
+			continue
+		}
+		if !slices.Contains(result, client.PanicTypes[i]) {
+			result = append(result, client.PanicTypes[i])
+		}
+	}
+	return result
+}
+
+type pgmConfig struct {
+	number int
+	name   string
+	pulse  bool
+}
+
+// pgms parses the PGMs config, each as "number[:name[:pulse]]", e.g.
+// "1:Gate:pulse", ignoring invalid ones.
+func (c Config) pgms() []pgmConfig {
+	var result []pgmConfig
+	for _, s := range c.PGMs {
+		parts := strings.Split(s, ":")
+		number, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || number < 1 || len(parts) > 3 {
+			log.Warn("invalid pgm, ignoring it", "pgm", s)
+			continue
+		}
+		pgm := pgmConfig{
+			number: number,
+			name:   fmt.Sprintf("PGM %d", number),
+		}
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			pgm.name = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			if strings.TrimSpace(parts[2]) != "pulse" {
+				log.Warn("invalid pgm mode, ignoring it", "pgm", s)
+				continue
+			}
+			pgm.pulse = true
+		}
+		result = append(result, pgm)
+	}
+	return result
+}
+
+// supported removes the zones, sirens, repeaters and partition sensors the
+// alarm system model does not have, so they are never looked up in its
+// status.
+func (c Config) supported(caps client.Capabilities) Config {
+	c.MotionZones = inRange("zone", c.MotionZones, 1, caps.Zones)
+	c.ContactZones = inRange("zone", c.ContactZones, 1, caps.Zones)
+	c.BypassZones = inRange("zone", c.BypassZones, 1, caps.Zones)
+	c.Sirens = inRange("siren", c.Sirens, 1, caps.Sirens)
+	c.Repeaters = inRange("repeater", c.Repeaters, 1, caps.Repeaters)
+	c.PartitionSensors = inRange("partition", c.PartitionSensors, 0, caps.Partitions-1)
+	return c
+}
+
+func inRange(kind string, numbers []int, lo, hi int) []int {
+	var result []int
+	for _, n := range numbers {
+		if n < lo || n > hi {
+			log.Warn("alarm system does not have "+kind+", ignoring it", kind, n)
+			continue
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
 func (c Config) getAlarmState(status client.Status) int {
 	if status.Siren {
 		return characteristic.SecuritySystemCurrentStateAlarmTriggered
 	}
+	for _, part := range status.Partitions {
+		if part.Enabled && part.Firing {
+			return characteristic.SecuritySystemCurrentStateAlarmTriggered
+		}
+	}
+
+	// the alarm system is not armed until the exit delay is over, and until
+	// then the current state is kept as is.
+	if status.State == client.StateArming || status.ExitDelay() {
+		return -1
+	}
 
 	switch status.State {
 	case client.StateDisarmed:
@@ -118,19 +276,47 @@ func (c Config) getAlarmState(status client.Status) int {
 	case client.StatePartial:
 		return c.getPartialStatus(status.Partitions)
 	default:
-		return c.getArmedState()
+		if state := c.getArmedState(status.Partitions); state >= 0 {
+			return state
+		}
+		return c.getPartialStatus(status.Partitions)
 	}
 }
 
-func (c Config) getArmedState() int {
-	if len(c.NightPartitions) == 1 && c.NightPartitions[0] == 0 {
-		return characteristic.SecuritySystemCurrentStateNightArm
+// armMode is how a HomeKit mode arms the alarm system: which partitions, and
+// which of them using the alarm system own stay mode.
+type armMode struct {
+	state      int
+	partitions []int
+	native     []int
+}
+
+func (c Config) armModes() []armMode {
+	return []armMode{
+		{characteristic.SecuritySystemCurrentStateNightArm, c.NightPartitions, c.NightNative},
+		{characteristic.SecuritySystemCurrentStateStayArm, c.StayPartitions, c.StayNative},
+		{characteristic.SecuritySystemCurrentStateAwayArm, c.AwayPartitions, c.AwayNative},
 	}
-	if len(c.StayPartitions) == 1 && c.StayPartitions[0] == 0 {
-		return characteristic.SecuritySystemCurrentStateStayArm
+}
+
+func (c Config) getArmedState(partitions []client.Partition) int {
+	var stay bool
+	for _, part := range partitions {
+		stay = stay || part.Stay
 	}
-	if len(c.AwayPartitions) == 1 && c.AwayPartitions[0] == 0 {
-		return characteristic.SecuritySystemCurrentStateAwayArm
+
+	// prefer the mode that arms in the same way (stay or not), falling back
+	// to any mode that arms all partitions.
+	for _, strict := range []bool{true, false} {
+		for _, mode := range c.armModes() {
+			if !slices.Equal(mode.partitions, []int{0}) {
+				continue
+			}
+			if strict && slices.Contains(mode.native, 0) != stay {
+				continue
+			}
+			return mode.state
+		}
 	}
 
 	return -1
@@ -138,24 +324,43 @@ func (c Config) getArmedState() int {
 
 func (c Config) getPartialStatus(partitions []client.Partition) int {
 	armed := []int{}
+	stay := []int{}
 	for _, part := range partitions {
-		log.Debug("partition armed", "part", part.Number, "armed", part.Armed)
-		if !part.Armed {
+		log.Debug("partition armed", "part", part.Number, "armed", part.Armed, "stay", part.Stay)
+		if !part.Armed && !part.Stay {
 			continue
 		}
 		armed = append(armed, part.Number)
-	}
-	if slices.Equal(c.NightPartitions, armed) {
-		return characteristic.SecuritySystemCurrentStateNightArm
-	}
-
-	if slices.Equal(c.StayPartitions, armed) {
-		return characteristic.SecuritySystemCurrentStateStayArm
+		if part.Stay {
+			stay = append(stay, part.Number)
+		}
 	}
 
-	if slices.Equal(c.AwayPartitions, armed) {
-		return characteristic.SecuritySystemCurrentStateAwayArm
+	// prefer the mode that arms the same partitions in the same way (stay or
+	// not), falling back to any mode that arms the same partitions.
+	for _, strict := range []bool{true, false} {
+		for _, mode := range c.armModes() {
+			if !slices.Equal(mode.partitions, armed) {
+				continue
+			}
+			if strict && !slices.Equal(mode.nativeOf(armed), stay) {
+				continue
+			}
+			return mode.state
+		}
 	}
 
 	return -1
 }
+
+// nativeOf returns which of the given partitions this mode arms using the
+// alarm system stay mode.
+func (m armMode) nativeOf(partitions []int) []int {
+	result := []int{}
+	for _, part := range partitions {
+		if slices.Contains(m.native, part) {
+			result = append(result, part)
+		}
+	}
+	return result
+}
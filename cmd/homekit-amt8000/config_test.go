@@ -18,19 +18,88 @@ func TestAllZones(t *testing.T) {
 	zones := cfg.allZones()
 
 	require.Equal(t, []zoneConfig{
-		{1, "A", kindContact, false},
-		{2, "B", kindMotion, false},
-		{3, "Zone 3", kindContact, false},
-		{4, "C", kindMotion, false},
-		{5, "D", kindContact, false},
-		{6, "Zone 6", kindContact, false},
-		{7, "Zone 7", kindContact, false},
-		{8, "Zone 8", kindMotion, false},
-		{9, "Zone 9", kindMotion, false},
-		{10, "Zone 10", kindMotion, false},
+		{1, "A", kindContact, false, false},
+		{2, "B", kindMotion, false, false},
+		{3, "Zone 3", kindContact, false, false},
+		{4, "C", kindMotion, false, false},
+		{5, "D", kindContact, false, false},
+		{6, "Zone 6", kindContact, false, false},
+		{7, "Zone 7", kindContact, false, false},
+		{8, "Zone 8", kindMotion, false, false},
+		{9, "Zone 9", kindMotion, false, false},
+		{10, "Zone 10", kindMotion, false, false},
 	}, zones)
 }
 
+func TestSupported(t *testing.T) {
+	cfg := Config{
+		ContactZones:     []int{0, 1, 18},
+		MotionZones:      []int{2, 20},
+		BypassZones:      []int{1, 30},
+		Sirens:           []int{1, 2},
+		Repeaters:        []int{3},
+		PartitionSensors: []int{0, 3, 4},
+	}.supported(client.Capabilities{
+		Zones:      18,
+		Partitions: 4,
+		Sirens:     1,
+		Repeaters:  2,
+	})
+
+	require.Equal(t, []int{1, 18}, cfg.ContactZones)
+	require.Equal(t, []int{2}, cfg.MotionZones)
+	require.Equal(t, []int{1}, cfg.BypassZones)
+	require.Equal(t, []int{1}, cfg.Sirens)
+	require.Empty(t, cfg.Repeaters)
+	require.Equal(t, []int{0, 3}, cfg.PartitionSensors)
+}
+
+func TestWithAutoZones(t *testing.T) {
+	status := client.Status{Zones: make([]client.Zone, 64)}
+	for i := range status.Zones {
+		status.Zones[i] = client.Zone{Number: i + 1, Enabled: i < 6}
+	}
+	prog := client.Programming{Zones: make([]client.ZoneProgramming, 64)}
+	prog.Zones[1].Sensor = client.SensorMotion
+	prog.Zones[2].Sensor = client.SensorContact
+	prog.Zones[3].Sensor = client.SensorButton
+
+	cfg := Config{
+		MotionZones: []int{1},
+		AutoZones:   true,
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		require.Equal(t, Config{MotionZones: []int{1}}, Config{
+			MotionZones: []int{1},
+		}.withAutoZones(status, prog))
+	})
+
+	t.Run("from programming", func(t *testing.T) {
+		cfg := cfg
+		cfg.AutoZonesKind = "contact"
+		cfg = cfg.withAutoZones(status, prog)
+		require.Equal(t, []int{1, 2}, cfg.MotionZones)
+		require.Equal(t, []int{3, 5, 6}, cfg.ContactZones)
+	})
+
+	t.Run("default motion", func(t *testing.T) {
+		cfg := cfg
+		cfg.AutoZonesKind = "motion"
+		cfg = cfg.withAutoZones(status, prog)
+		require.Equal(t, []int{1, 2, 5, 6}, cfg.MotionZones)
+		require.Equal(t, []int{3}, cfg.ContactZones)
+	})
+
+	t.Run("unknown programming", func(t *testing.T) {
+		cfg := cfg
+		cfg.AutoZonesKind = "motion"
+		cfg = cfg.withAutoZones(status, client.Programming{})
+		require.Equal(t, []int{1, 2, 3, 4, 5, 6}, cfg.MotionZones)
+		require.Empty(t, cfg.ContactZones)
+	})
+}
+
 func TestGetAlarmState(t *testing.T) {
 	cfg := Config{
 		StayPartitions:  []int{1, 3},
@@ -48,6 +117,35 @@ func TestGetAlarmState(t *testing.T) {
 		)
 	})
 
+	t.Run("partition firing", func(t *testing.T) {
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateAlarmTriggered,
+			cfg.getAlarmState(client.Status{
+				State: client.StateArmed,
+				Partitions: []client.Partition{
+					{Number: 1, Enabled: true, Armed: true, Firing: true},
+				},
+			}),
+		)
+	})
+
+	t.Run("exit delay", func(t *testing.T) {
+		require.Equal(t, -1, cfg.getAlarmState(client.Status{
+			State: client.StateArming,
+			Partitions: []client.Partition{
+				{Number: 0, Enabled: true, ExitDelay: true},
+			},
+		}))
+		require.Equal(t, -1, cfg.getAlarmState(client.Status{
+			State: client.StatePartial,
+			Partitions: []client.Partition{
+				{Number: 1, Enabled: true, Armed: true},
+				{Number: 3, Enabled: true, ExitDelay: true},
+			},
+		}))
+	})
+
 	t.Run("night", func(t *testing.T) {
 		require.Equal(
 			t,
@@ -103,4 +201,48 @@ func TestGetAlarmState(t *testing.T) {
 			}),
 		)
 	})
+
+	t.Run("native stay", func(t *testing.T) {
+		cfg := Config{
+			StayPartitions:  []int{0},
+			StayNative:      []int{0},
+			NightPartitions: []int{1, 2},
+			NightNative:     []int{2},
+			AwayPartitions:  []int{0},
+		}
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateStayArm,
+			cfg.getAlarmState(client.Status{
+				State: client.StateArmed,
+				Partitions: []client.Partition{
+					{Number: 1, Armed: true, Stay: true},
+					{Number: 2, Armed: true, Stay: true},
+				},
+			}),
+		)
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateAwayArm,
+			cfg.getAlarmState(client.Status{
+				State: client.StateArmed,
+				Partitions: []client.Partition{
+					{Number: 1, Armed: true},
+					{Number: 2, Armed: true},
+				},
+			}),
+		)
+		require.Equal(
+			t,
+			characteristic.SecuritySystemCurrentStateNightArm,
+			cfg.getAlarmState(client.Status{
+				State: client.StatePartial,
+				Partitions: []client.Partition{
+					{Number: 1, Armed: true},
+					{Number: 2, Armed: true, Stay: true},
+					{Number: 3},
+				},
+			}),
+		)
+	})
 }
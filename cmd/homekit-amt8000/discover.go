@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+const discoverUsage = `usage:
+  homekit-amt8000 discover [-port 9009] [-timeout 1s] [-auth] <network, e.g. 192.168.1.0/24>
+
+With -auth, it logs into the alarm systems found with $PASSWORD to also show
+their model and version.`
+
+// discoverCommand looks for alarm systems in the given network.
+func discoverCommand(ctx context.Context, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	var scanner client.Scanner
+	flags.StringVar(&scanner.Port, "port", "9009", "")
+	flags.DurationVar(&scanner.Timeout, "timeout", time.Second, "")
+	auth := flags.Bool("auth", false, "")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w\n%s", err, discoverUsage)
+	}
+	if flags.NArg() != 1 {
+		return errors.New(discoverUsage)
+	}
+	if *auth {
+		scanner.Password = os.Getenv("PASSWORD")
+		if scanner.Password == "" {
+			return errors.New("-auth needs $PASSWORD to be set")
+		}
+	}
+
+	panels, err := scanner.Scan(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(panels) == 0 {
+		_, err := fmt.Fprintln(w, "no alarm systems found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IP\tPORT\tMAC\tMODEL\tVERSION")
+	for _, panel := range panels {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			panel.IP,
+			panel.Port,
+			orDash(panel.MAC),
+			orDash(panel.Model),
+			orDash(panel.Version),
+		)
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverCommand(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	t.Setenv("PASSWORD", panel.Password)
+
+	var out bytes.Buffer
+	require.NoError(t, discoverCommand(context.Background(), []string{"-port", panel.Port(), "-auth", "127.0.0.1/32"}, &out))
+	require.Equal(t, "IP         PORT   MAC  MODEL     VERSION\n127.0.0.1  "+panel.Port()+"  -    AMT-8000  2.5.3\n", out.String())
+
+	// without -auth, it doesn't log in even if $PASSWORD is set.
+	out.Reset()
+	require.NoError(t, discoverCommand(context.Background(), []string{"-port", panel.Port(), "127.0.0.1/32"}, &out))
+	require.Equal(t, "IP         PORT   MAC  MODEL  VERSION\n127.0.0.1  "+panel.Port()+"  -    -      -\n", out.String())
+
+	out.Reset()
+	require.NoError(t, discoverCommand(context.Background(), []string{"-port", "1", "127.0.0.1/32"}, &out))
+	require.Equal(t, "no alarm systems found\n", out.String())
+
+	require.Error(t, discoverCommand(context.Background(), nil, &out))
+	require.Error(t, discoverCommand(context.Background(), []string{"nope"}, &out))
+
+	t.Setenv("PASSWORD", "")
+	require.Error(t, discoverCommand(context.Background(), []string{"-auth", "127.0.0.1/32"}, &out))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/service"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// EntryDelaySensor shows whether an entry delay is running as a contact
+// sensor, so automations can e.g. turn the lights on or remind to disarm.
+type EntryDelaySensor struct {
+	*accessory.A
+	EntryDelay *service.ContactSensor
+}
+
+func newEntryDelaySensor(info accessory.Info) *EntryDelaySensor {
+	a := EntryDelaySensor{}
+	a.A = accessory.New(info, accessory.TypeSensor)
+
+	a.EntryDelay = service.NewContactSensor()
+	a.AddS(a.EntryDelay.S)
+
+	return &a
+}
+
+// Handle shows whether any partition entry delay is running.
+func (a *EntryDelaySensor) Handle(change client.Change, status client.Status) {
+	if change.Kind != client.ChangePartitionEntryDelay {
+		return
+	}
+	running := boolAs[int](status.EntryDelay())
+	if a.EntryDelay.ContactSensorState.Value() != running {
+		log.Warn("entry delay", "running", running == 1)
+		_ = a.EntryDelay.ContactSensorState.SetValue(running)
+	}
+}
+
+func setupEntryDelay(cfg Config, status client.Status) []*EntryDelaySensor {
+	if !cfg.EntryDelaySensor {
+		return nil
+	}
+	a := newEntryDelaySensor(accessory.Info{
+		Name:         "Entry Delay",
+		Manufacturer: manufacturer,
+	})
+	a.Id = 800
+	initialize(a, status)
+	return []*EntryDelaySensor{a}
+}
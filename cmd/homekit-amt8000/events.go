@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// logEntry is a client.LogEntry with its human readable description.
+type logEntry struct {
+	client.LogEntry
+	Description string
+}
+
+// eventsHandler dumps the alarm system event log as JSON.
+// The 'from' and 'count' query parameters can be used to paginate it.
+func eventsHandler(execute Executor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, err := intParam(r, "from", 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		count, err := intParam(r, "count", 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if from < 0 || count < 1 || count > 255 {
+			http.Error(w, "from must be positive and count between 1 and 255", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		entries := []logEntry{}
+		if err := execute(ctx, func(cli *client.Client) error {
+			result, err := cli.EventsContext(ctx, from, count)
+			if err != nil {
+				return err
+			}
+			for _, entry := range result {
+				entries = append(entries, logEntry{
+					LogEntry:    entry,
+					Description: entry.Description(),
+				})
+			}
+			return nil
+		}); err != nil {
+			log.Error("could not read event log", "err", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
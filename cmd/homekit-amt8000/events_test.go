@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/caarlos0/homekit-amt8000/contactid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsHandler(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	at := time.Date(2024, time.March, 10, 22, 15, 30, 0, time.Local)
+	for i := 1; i <= 5; i++ {
+		panel.AddLogEntry(amt8000test.LogEntry{Time: at, Code: 401, Partition: 1, User: i})
+	}
+	handler := eventsHandler(newTestExecutor(t, panel))
+
+	t.Run("default", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var entries []logEntry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+		require.Len(t, entries, 5)
+		require.Equal(t, 5, entries[0].User)
+		require.Equal(t, contactid.CodeOpenClose, entries[0].Code)
+		require.Equal(t, "Disarmed: Open/close by user", entries[0].Description)
+		require.True(t, at.Equal(entries[0].Time))
+	})
+
+	t.Run("paginated", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?from=1&count=2", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []client.LogEntry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+		require.Len(t, entries, 2)
+		require.Equal(t, 4, entries[0].User)
+		require.Equal(t, 3, entries[1].User)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?from=10", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, "[]", rec.Body.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?count=abc", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?count=0", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -10,9 +10,10 @@ import (
 	"os"
 	"os/signal"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/brutella/hap"
 	"github.com/brutella/hap/accessory"
@@ -21,6 +22,7 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	logp "github.com/charmbracelet/log"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/exp/slices"
 )
 
 //go:embed index.html
@@ -38,7 +40,7 @@ var (
 	date    = "unknown"
 )
 
-type Executor = func(func(cli *client.Client) error) error
+type Executor = func(ctx context.Context, fn func(cli *client.Client) error) error
 
 const (
 	manufacturer = "Intelbras"
@@ -46,6 +48,13 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	log.Info(
 		"homekit-amt8000",
 		"version", version,
@@ -67,60 +76,54 @@ func main() {
 		)
 	}
 
-	log.Info(
-		"loading accessories",
-		"partitions",
-		strings.Join([]string{
-			fmt.Sprintf("stay: %v", cfg.StayPartitions),
-			fmt.Sprintf("away: %v", cfg.AwayPartitions),
-			fmt.Sprintf("night: %v", cfg.NightPartitions),
-		}, "\n"),
-		"zones", allZoneConfigs(cfg.allZones()).String(),
-	)
+	// the alarm system clock and event log are in its local time.
+	timezone := time.Local
+	if cfg.Timezone != nil {
+		timezone = cfg.Timezone
+	}
+	if cfg.SyncTime && timezone.String() == "UTC" {
+		log.Warn("syncing the alarm system clock to UTC, set TIMEZONE if it is not what the alarm system should use")
+	}
 
-	var clientLock sync.Mutex
-	execute := func(fn func(cli *client.Client) error) error {
-		t := time.Now()
-		clientLock.Lock()
-		defer clientLock.Unlock()
-		log.Debugf("got client lock after %s", time.Since(t))
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, syscall.SIGTERM)
 
-		bo := backoff.NewExponentialBackOff()
-		bo.MaxInterval = time.Second * 5
-		bo.MaxElapsedTime = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-c
+		log.Info("stopping server")
+		signal.Stop(c)
+		cancel()
+	}()
 
-		return backoff.RetryNotify(func() error {
-			requestCounter.Inc()
-			cli, err := client.New(cfg.Host, cfg.Port, cfg.Password, cfg.ClientTimeout)
-			if err != nil {
-				return fmt.Errorf("could not init isecnet2 client: %w", err)
-			}
-			defer func() {
-				if err := cli.Close(); err != nil {
-					log.Error("could not close isecnet2 client", "err", err)
-				}
-			}()
-			if err := fn(cli); err != nil {
-				requestErrorCounter.Inc()
-				if errors.Is(err, client.ErrOpenZones) ||
-					errors.Is(err, client.ErrInvalidPassword) {
-					return backoff.Permanent(err)
-				}
-				return err
-			}
-			return nil
-		}, bo, func(err error, _ time.Duration) {
-			log.Error("command to central failed", "err", err)
-		})
-	}
+	session := client.NewSession(
+		cfg.Host,
+		cfg.Port,
+		cfg.Password,
+		cfg.ClientTimeout,
+		cfg.KeepAliveInterval,
+	)
+	session.SetLocation(timezone)
+	defer func() {
+		if err := session.Close(); err != nil {
+			log.Error("could not close isecnet2 session", "err", err)
+		}
+	}()
+
+	execute := newExecutor(session)
 
 	var status client.Status
-	if err := execute(func(cli *client.Client) (err error) {
-		status, err = cli.Status()
+	if err := execute(ctx, func(cli *client.Client) (err error) {
+		status, err = cli.StatusContext(ctx)
 		return
 	}); err != nil {
 		log.Fatal("could not init accessories", "err", err)
 	}
+
+	// not retried, as the alarm system might just not answer it.
+	levels := &signalLevels{execute: session.DoContext}
+	levels.read(ctx, &status)
 	macAddr, err := client.MacAddress(cfg.Host)
 	if err != nil {
 		log.Warn(
@@ -134,6 +137,48 @@ func main() {
 		"model", status.Model,
 		"version", status.Version,
 		"mac", macAddr,
+		"zones", status.Capabilities.Zones,
+		"partitions", status.Capabilities.Partitions,
+		"sirens", status.Capabilities.Sirens,
+		"repeaters", status.Capabilities.Repeaters,
+	)
+	cfg = cfg.supported(status.Capabilities)
+
+	var prog client.Programming
+	if err := execute(ctx, func(cli *client.Client) (err error) {
+		prog, err = cli.ProgrammingContext(ctx)
+		return
+	}); err != nil {
+		log.Warn("could not read the alarm system programming, not validating the configuration", "err", err)
+	} else {
+		log.Info("got alarm system programming", "exit delay", prog.ExitDelay)
+		for _, problem := range cfg.validate(prog) {
+			log.Warn("configuration does not match the alarm system programming", "problem", problem)
+		}
+	}
+	cfg = cfg.withAutoZones(status, prog)
+
+	if cfg.PanelNames && len(cfg.ZoneNames) == 0 {
+		var names client.Names
+		if err := execute(ctx, func(cli *client.Client) (err error) {
+			names, err = cli.NamesContext(ctx)
+			return
+		}); err != nil {
+			log.Warn("could not get names from the alarm system", "err", err)
+		} else {
+			cfg.ZoneNames = names.Zones
+		}
+	}
+
+	log.Info(
+		"loading accessories",
+		"partitions",
+		strings.Join([]string{
+			fmt.Sprintf("stay: %v", cfg.StayPartitions),
+			fmt.Sprintf("away: %v", cfg.AwayPartitions),
+			fmt.Sprintf("night: %v", cfg.NightPartitions),
+		}, "\n"),
+		"zones", allZoneConfigs(cfg.allZones()).String(),
 	)
 
 	bridge := accessory.NewBridge(accessory.Info{
@@ -150,49 +195,118 @@ func main() {
 		Firmware:     status.Version,
 	}, cfg, execute)
 	alarm.Id = 2
+	initialize(alarm, status)
 
 	if state := cfg.getAlarmState(status); state >= 0 {
 		err := alarm.SecuritySystem.SecuritySystemTargetState.SetValue(state)
 		log.Info("set target state", "state", state, "err", err)
 	}
 
-	panicBtn := setupPanicButton(execute)
-	panicBtn.Id = 3
+	panicBtns := setupPanicButtons(execute, cfg)
+
+	memoryBtns := setupAlarmMemory(execute, cfg, status)
 
 	sensors := setupZones(execute, cfg, status)
 	sirens := setupSirens(cfg, status)
 	repeaters := setupRepeaters(cfg, status)
+	partitions := setupPartitions(cfg, status)
+	pgms := setupPGMs(execute, cfg, status)
+	entryDelay := setupEntryDelay(cfg, status)
+	troubles := setupTroubles(cfg, status)
+	troubleGauges := setupTroubleGauges(status)
+	memory := setupMemoryGauge(status)
+
+	handlers := []changeHandler{alarm, troubleGauges, memory}
+	handlers = appendHandlers(handlers, memoryBtns)
+	handlers = appendHandlers(handlers, panicBtns)
+	handlers = appendHandlers(handlers, sensors)
+	handlers = appendHandlers(handlers, sirens)
+	handlers = appendHandlers(handlers, repeaters)
+	handlers = appendHandlers(handlers, partitions)
+	handlers = appendHandlers(handlers, pgms)
+	handlers = appendHandlers(handlers, entryDelay)
+	handlers = appendHandlers(handlers, troubles)
+	updateSignalGauges(cfg, status, sensors, sirens, repeaters)
+
+	// the latest status, for the web page.
+	var current atomic.Pointer[client.Status]
+	current.Store(&status)
+
+	// the clock is synced again as soon as the alarm system reports it lost
+	// track of time, e.g. after a power loss.
+	checkClockNow := make(chan struct{}, 1)
+	if cfg.SyncTime {
+		go watchClock(ctx, execute, checkClockNow)
+	}
+
+	// events make the status be refreshed right away, the ticker is only a
+	// fallback to reconcile anything that was missed.
+	refresh := make(chan struct{}, 1)
+	go func() {
+		for event := range session.Events() {
+			eventCounter.Inc()
+			log.Info("got event", "event", event)
+			select {
+			case refresh <- struct{}{}:
+			default:
+			}
+		}
+	}()
 
 	go func() {
 		tick := time.NewTicker(cfg.StatusInterval)
-		for range tick.C {
+		defer tick.Stop()
+		prev := status
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			case <-refresh:
+			}
+
 			var status client.Status
-			if err := execute(func(cli *client.Client) (err error) {
-				status, err = cli.Status()
+			if err := execute(ctx, func(cli *client.Client) (err error) {
+				status, err = cli.StatusContext(ctx)
 				return
 			}); err != nil {
 				log.Error("could not get status", "err", err)
 				continue
 			}
+			levels.read(ctx, &status)
+
+			for _, change := range status.Diff(prev) {
+				changeCounter.WithLabelValues(change.Kind.String()).Inc()
+				log.Debug("status changed", "change", change)
+				if change.Kind == client.ChangeClockNotSet && change.Set {
+					select {
+					case checkClockNow <- struct{}{}:
+					default:
+					}
+				}
+				if change.Kind == client.ChangeZoneFiring && change.Set {
+					log.Warn(
+						"zone triggered the alarm",
+						"zone", change.Number,
+						"name", cfg.zoneName(change.Number),
+					)
+				}
+				for _, h := range handlers {
+					h.Handle(change, status)
+				}
+			}
+			prev = status
 
-			alarm.Update(status)
-			panicBtn.Switch.On.SetValue(status.Siren)
+			current.Store(&status)
+			updateSignalGauges(cfg, status, sensors, sirens, repeaters)
 
+			// arming attempts are not status changes, so the zones that
+			// prevented the last one are checked on every status read.
 			if len(status.Zones) >= len(cfg.allZones()) {
+				blocking := alarm.Blocking()
 				for i, zi := range cfg.allZones() {
 					zone := status.Zones[zi.number-1]
-					sensor := sensors[i]
-					sensor.Update(zone)
-				}
-			}
-			if len(status.Sirens) >= len(cfg.Sirens) {
-				for i, number := range cfg.Sirens {
-					sirens[i].Update(status.Sirens[number-1])
-				}
-			}
-			if len(status.Repeaters) >= len(cfg.Repeaters) {
-				for i, number := range cfg.Repeaters {
-					repeaters[i].Update(status.Repeaters[number-1])
+					sensors[i].SetBlocking(slices.Contains(blocking, zi.number) && zone.IsOpen())
 				}
 			}
 		}
@@ -202,13 +316,14 @@ func main() {
 
 	server, err := hap.NewServer(
 		fs, bridge.A,
-		securityAccessories(sensors, sirens, repeaters, alarm, panicBtn)...,
+		securityAccessories(sensors, sirens, repeaters, partitions, troubles, pgms, entryDelay, alarm, panicBtns, memoryBtns)...,
 	)
 	if err != nil {
 		log.Fatal("fail to create server", "error", err)
 	}
 	server.Addr = cfg.Address
 	server.ServeMux().Handle("/metrics", promhttp.Handler())
+	server.ServeMux().Handle("/events", eventsHandler(execute))
 	server.ServeMux().Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		state := [5]string{
 			"Armed: Stay",
@@ -219,12 +334,18 @@ func main() {
 		}[alarm.SecuritySystem.SecuritySystemCurrentState.Value()]
 
 		var hSensors []PageItem
+		blocking := alarm.Blocking()
 		for i, zone := range sensors {
 			z := PageItem{
 				Number:     i + 1,
 				Name:       zone.Name(),
 				Tamper:     zone.Tamper.Value() == 1,
 				LowBattery: zone.LowBattery.Value() == 1,
+				Blocking:   slices.Contains(blocking, zone.zone.number),
+				Firing:     zone.firing.Load(),
+				Fired:      zone.fired.Load(),
+
+				Unsupervised: zone.unsupervised.Load(),
 			}
 			if zone.Motion != nil {
 				z.Open = zone.Motion.MotionDetected.Value()
@@ -244,6 +365,8 @@ func main() {
 				Name:       siren.Name(),
 				Tamper:     siren.Tamper.Value() == 1,
 				LowBattery: siren.LowBattery.Value() == 1,
+
+				Unsupervised: siren.Fault.Value() == 1,
 			})
 		}
 
@@ -254,51 +377,126 @@ func main() {
 				Name:       repeater.Name(),
 				Tamper:     repeater.Tamper.Value() == 1,
 				LowBattery: repeater.LowBattery.Value() == 1,
+
+				Unsupervised: repeater.Fault.Value() == 1,
+			})
+		}
+
+		status := current.Load()
+		var hPartitions []PartitionItem
+		for _, part := range status.Partitions {
+			if !part.Enabled {
+				continue
+			}
+			hPartitions = append(hPartitions, PartitionItem{
+				Number: part.Number,
+				Armed:  part.Armed,
+				Stay:   part.Stay,
+				Firing: part.Firing,
+				Fired:  part.Fired,
 			})
 		}
 
 		tpl := template.Must(template.New("index").Parse(string(index)))
 		_ = tpl.Execute(w, struct {
-			State     string
-			Zones     []PageItem
-			Sirens    []PageItem
-			Repeaters []PageItem
+			State      string
+			Memory     bool
+			Zones      []PageItem
+			Partitions []PartitionItem
+			Sirens     []PageItem
+			Repeaters  []PageItem
 		}{
-			State:     state,
-			Zones:     hSensors,
-			Sirens:    hSirens,
-			Repeaters: hRepeaters,
+			State:      state,
+			Memory:     alarmMemory(*status),
+			Zones:      hSensors,
+			Partitions: hPartitions,
+			Sirens:     hSirens,
+			Repeaters:  hRepeaters,
 		})
 	}))
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	signal.Notify(c, syscall.SIGTERM)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		<-c
-		log.Info("stopping server")
-		signal.Stop(c)
-		cancel()
-	}()
-
 	log.Info("starting server", "addr", server.Addr)
 	if err := server.ListenAndServe(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Error("failed to close server", "err", err)
 	}
 }
 
+// newExecutor returns an Executor that runs commands in the given session,
+// retrying failures with an exponential backoff.
+func newExecutor(session *client.Session) Executor {
+	return func(ctx context.Context, fn func(cli *client.Client) error) error {
+		bo := backoff.NewExponentialBackOff()
+		bo.MaxInterval = time.Second * 5
+		bo.MaxElapsedTime = time.Minute
+
+		return backoff.RetryNotify(func() error {
+			requestCounter.Inc()
+			if err := session.DoContext(ctx, fn); err != nil {
+				requestErrorCounter.Inc()
+				if isPermanent(err) {
+					return backoff.Permanent(err)
+				}
+				return err
+			}
+			return nil
+		}, backoff.WithContext(bo, ctx), func(err error, _ time.Duration) {
+			log.Error("command to central failed", "err", err)
+		})
+	}
+}
+
+// isPermanent tells whether retrying would not help, e.g. the alarm system
+// refused the command.
+func isPermanent(err error) bool {
+	var refused client.RefusedError
+	return errors.As(err, &refused) ||
+		errors.Is(err, client.ErrOpenZones) ||
+		errors.Is(err, client.ErrInvalidPassword) ||
+		errors.Is(err, client.ErrInvalidZone) ||
+		errors.Is(err, client.ErrInvalidPartition) ||
+		errors.Is(err, client.ErrInvalidPGM)
+}
+
+// hapStatus translates an error running a command into a HAP status code.
+func hapStatus(err error) int {
+	switch {
+	case errors.Is(err, client.ErrNotPermitted):
+		return hap.JsonStatusInsufficientPrivileges
+	case errors.Is(err, client.ErrInvalidZone),
+		errors.Is(err, client.ErrInvalidPartition),
+		errors.Is(err, client.ErrInvalidPGM):
+		return hap.JsonStatusResourceDoesNotExist
+	case errors.Is(err, client.ErrOpenZones):
+		return hap.JsonStatusResourceBusy
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded):
+		return hap.JsonStatusOperationTimedOut
+	}
+	var refused client.RefusedError
+	if errors.As(err, &refused) {
+		return hap.JsonStatusInvalidValueInRequest
+	}
+	return hap.JsonStatusServiceCommunicationFailure
+}
+
 func securityAccessories(
 	sensors []*AlarmSensor,
 	sirens []*Siren,
 	repeaters []*Repeater,
+	partitions []*PartitionSensor,
+	troubles []*TroubleSensor,
+	pgms []*PGMSwitch,
+	entryDelay []*EntryDelaySensor,
 	alarm *SecuritySystem,
-	panicBtn *accessory.Switch,
+	panicBtns []*PanicButton,
+	memoryBtns []*AlarmMemorySwitch,
 ) []*accessory.A {
-	result := []*accessory.A{
-		panicBtn.A,
-		alarm.A,
+	result := []*accessory.A{alarm.A}
+	for _, c := range memoryBtns {
+		result = append(result, c.A)
+	}
+	for _, c := range panicBtns {
+		result = append(result, c.A)
 	}
 	for _, c := range sensors {
 		result = append(result, c.A)
@@ -309,9 +507,56 @@ func securityAccessories(
 	for _, c := range repeaters {
 		result = append(result, c.A)
 	}
+	for _, c := range partitions {
+		result = append(result, c.A)
+	}
+	for _, c := range troubles {
+		result = append(result, c.A)
+	}
+	for _, c := range pgms {
+		result = append(result, c.A)
+	}
+	for _, c := range entryDelay {
+		result = append(result, c.A)
+	}
 	return result
 }
 
+// updateSignalGauges exports the signal levels of the wireless devices.
+// They are not status changes, so they are exported on every status read.
+func updateSignalGauges(
+	cfg Config,
+	status client.Status,
+	sensors []*AlarmSensor,
+	sirens []*Siren,
+	repeaters []*Repeater,
+) {
+	if len(status.Zones) >= len(cfg.allZones()) {
+		for i, zone := range cfg.allZones() {
+			signalGauge.WithLabelValues(sensors[i].Name()).Set(float64(status.Zones[zone.number-1].SignalLevel))
+		}
+	}
+	if len(status.Sirens) >= len(cfg.Sirens) {
+		for i, number := range cfg.Sirens {
+			signalGauge.WithLabelValues(sirens[i].Name()).Set(float64(status.Sirens[number-1].SignalLevel))
+		}
+	}
+	if len(status.Repeaters) >= len(cfg.Repeaters) {
+		for i, number := range cfg.Repeaters {
+			signalGauge.WithLabelValues(repeaters[i].Name()).Set(float64(status.Repeaters[number-1].SignalLevel))
+		}
+	}
+}
+
+// requestContext returns the context of the HAP request, if any, so requests to
+// the alarm system are aborted when the HomeKit client gives up.
+func requestContext(r *http.Request) context.Context {
+	if r == nil {
+		return context.Background()
+	}
+	return r.Context()
+}
+
 func boolAs[T int | float64](b bool) T {
 	if b {
 		return 1
@@ -326,4 +571,17 @@ type PageItem struct {
 	Tamper     bool
 	Bypassed   bool
 	LowBattery bool
+	Blocking   bool
+	Firing     bool
+	Fired      bool
+
+	Unsupervised bool
+}
+
+type PartitionItem struct {
+	Number int
+	Armed  bool
+	Stay   bool
+	Firing bool
+	Fired  bool
 }
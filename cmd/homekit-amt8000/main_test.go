@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPermanent(t *testing.T) {
+	require.True(t, isPermanent(client.RefusedError{}))
+	require.True(t, isPermanent(client.OpenZonesError{Partition: 1, Zones: []int{5}}))
+	// the alarm system refused it, even if the open zones are unknown.
+	require.True(t, isPermanent(fmt.Errorf("could not arm 1: %w: could not read them: %w", client.ErrOpenZones, os.ErrDeadlineExceeded)))
+	require.True(t, isPermanent(fmt.Errorf("could not bypass: %w", client.ErrInvalidZone)))
+
+	require.False(t, isPermanent(os.ErrDeadlineExceeded))
+	require.False(t, isPermanent(errors.New("connection reset")))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/service"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// AlarmMemorySwitch is on while the alarm system remembers a firing, and
+// cleans the firings when turned off.
+type AlarmMemorySwitch struct {
+	*accessory.A
+	Switch *service.Switch
+}
+
+// Handle shows whether the alarm system remembers a firing.
+func (a *AlarmMemorySwitch) Handle(change client.Change, status client.Status) {
+	if !isMemoryChange(change.Kind) {
+		return
+	}
+	if memory := alarmMemory(status); a.Switch.On.Value() != memory {
+		a.Switch.On.SetValue(memory)
+	}
+}
+
+func setupAlarmMemory(execute Executor, cfg Config, status client.Status) []*AlarmMemorySwitch {
+	if !cfg.AlarmMemory {
+		return nil
+	}
+
+	a := &AlarmMemorySwitch{}
+	a.A = accessory.New(accessory.Info{
+		Name:         "Alarm Memory",
+		Manufacturer: manufacturer,
+	}, accessory.TypeSwitch)
+
+	a.Switch = service.NewSwitch()
+	a.AddS(a.Switch.S)
+	a.Id = 4
+
+	a.Switch.On.SetValueRequestFunc = func(value interface{}, r *http.Request) (response interface{}, code int) {
+		if value.(bool) {
+			// only the alarm system can set it.
+			return nil, hap.JsonStatusInvalidValueInRequest
+		}
+		ctx := requestContext(r)
+		log.Info("cleaning firings")
+		if err := execute(ctx, func(cli *client.Client) error {
+			return cli.CleanFiringsContext(ctx)
+		}); err != nil {
+			log.Error("could not clean firings", "err", err)
+			return nil, hapStatus(err)
+		}
+		return nil, hap.JsonStatusSuccess
+	}
+	initialize(a, status)
+	return []*AlarmMemorySwitch{a}
+}
+
+// setupMemoryGauge returns the handler that exports whether the alarm system
+// remembers a firing, whether the switch is shown or not.
+func setupMemoryGauge(status client.Status) changeHandler {
+	memoryGauge.Set(0)
+	h := changeHandlerFunc(func(change client.Change, status client.Status) {
+		if isMemoryChange(change.Kind) {
+			memoryGauge.Set(boolAs[float64](alarmMemory(status)))
+		}
+	})
+	initialize(h, status)
+	return h
+}
+
+// isMemoryChange tells whether the change might change the alarm memory.
+func isMemoryChange(kind client.ChangeKind) bool {
+	switch kind {
+	case client.ChangeZoneFiring,
+		client.ChangeZoneFired,
+		client.ChangePartitionFiring,
+		client.ChangePartitionFired:
+		return true
+	default:
+		return false
+	}
+}
+
+// alarmMemory tells whether any partition or zone fired since the firings
+// were last cleaned.
+func alarmMemory(status client.Status) bool {
+	for _, part := range status.Partitions {
+		if part.Fired || part.Firing {
+			return true
+		}
+	}
+	for _, zone := range status.Zones {
+		if zone.Fired || zone.Firing {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brutella/hap"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlarmMemory(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetPartition(1, amt8000test.Partition{Enabled: true, Fired: true})
+
+	execute := newTestExecutor(t, panel)
+	require.Empty(t, setupAlarmMemory(execute, Config{}, testStatus(t, execute)))
+
+	memories := setupAlarmMemory(execute, Config{AlarmMemory: true}, testStatus(t, execute))
+	require.Len(t, memories, 1)
+	memory := memories[0]
+	require.Equal(t, uint64(4), memory.Id)
+	require.True(t, memory.Switch.On.Value())
+
+	_, code := memory.Switch.On.SetValueRequestFunc(true, nil)
+	require.Equal(t, hap.JsonStatusInvalidValueInRequest, code)
+
+	_, code = memory.Switch.On.SetValueRequestFunc(false, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.False(t, panel.Partition(1).Fired)
+	require.False(t, alarmMemory(testStatus(t, execute)))
+}
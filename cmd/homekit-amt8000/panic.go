@@ -2,28 +2,96 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/brutella/hap"
 	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/service"
 	client "github.com/caarlos0/homekit-amt8000"
 )
 
-func setupPanicButton(execute Executor) *accessory.Switch {
-	a := accessory.NewSwitch(accessory.Info{
-		Name:         "Audible Panic",
+var panicNames = map[client.PanicType]string{
+	client.PanicAudible: "Audible Panic",
+	client.PanicSilent:  "Silent Panic",
+	client.PanicMedical: "Medical Emergency",
+	client.PanicFire:    "Fire Emergency",
+}
+
+// momentaryReset is how long momentary panic switches stay on after being
+// triggered.
+const momentaryReset = time.Second
+
+type PanicButton struct {
+	*accessory.A
+	Switch *service.Switch
+
+	kind client.PanicType
+}
+
+// momentary tells whether the switch turns itself off after being
+// triggered.
+// Only the audible panic stays on, while the siren is on: the other panics
+// can't be told apart from other firings, nor should turning them off disarm
+// the alarm system.
+func (a *PanicButton) momentary() bool {
+	return a.kind != client.PanicAudible
+}
+
+// Handle shows the audible panic as on while the siren is on.
+func (a *PanicButton) Handle(change client.Change, status client.Status) {
+	if a.momentary() || change.Kind != client.ChangeSiren {
+		return
+	}
+	if a.Switch.On.Value() != change.Set {
+		a.Switch.On.SetValue(change.Set)
+	}
+}
+
+func setupPanicButtons(execute Executor, cfg Config) []*PanicButton {
+	var buttons []*PanicButton
+	for _, kind := range cfg.panicTypes() {
+		a := setupPanicButton(execute, kind)
+		// the audible panic keeps the id it always had.
+		a.Id = 600 + uint64(kind)
+		if kind == client.PanicAudible {
+			a.Id = 3
+		}
+		buttons = append(buttons, a)
+	}
+	return buttons
+}
+
+func setupPanicButton(execute Executor, kind client.PanicType) *PanicButton {
+	a := &PanicButton{kind: kind}
+	a.A = accessory.New(accessory.Info{
+		Name:         panicNames[kind],
 		Manufacturer: manufacturer,
-	})
-	a.Switch.On.SetValueRequestFunc = func(value interface{}, _ *http.Request) (response interface{}, code int) {
+	}, accessory.TypeSwitch)
+
+	a.Switch = service.NewSwitch()
+	a.AddS(a.Switch.S)
+
+	a.Switch.On.SetValueRequestFunc = func(value interface{}, r *http.Request) (response interface{}, code int) {
 		v := value.(bool)
-		if err := execute(func(cli *client.Client) error {
+		if !v && a.momentary() {
+			// it was already turned off by itself.
+			return nil, hap.JsonStatusSuccess
+		}
+		ctx := requestContext(r)
+		if err := execute(ctx, func(cli *client.Client) error {
 			if v {
-				log.Warn("triggering an audible panic!")
-				return cli.Panic()
+				log.Warn("triggering a panic!", "type", kind)
+				return cli.PanicContext(ctx, kind)
 			}
-			return cli.Disarm(client.AllPartitions)
+			return cli.DisarmContext(ctx, client.AllPartitions)
 		}); err != nil {
-			log.Error("failed to trigger an audible panic", "err", err)
-			return nil, hap.JsonStatusResourceBusy
+			log.Error("failed to trigger a panic", "type", kind, "err", err)
+			return nil, hapStatus(err)
+		}
+		if a.momentary() {
+			time.AfterFunc(momentaryReset, func() {
+				a.Switch.On.SetValue(false)
+			})
 		}
 		return nil, hap.JsonStatusSuccess
 	}
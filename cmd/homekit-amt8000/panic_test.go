@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brutella/hap"
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicButtons(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+
+	execute := newTestExecutor(t, panel)
+	buttons := setupPanicButtons(execute, Config{
+		PanicTypes: []string{"silent", "Audible", "nope", "fire", "silent"},
+	})
+	require.Len(t, buttons, 3)
+	require.Equal(t, uint64(601), buttons[0].Id)
+	require.Equal(t, uint64(3), buttons[1].Id)
+	require.Equal(t, uint64(603), buttons[2].Id)
+
+	silent, audible := buttons[0], buttons[1]
+
+	panel.SetPartition(1, amt8000test.Partition{Enabled: true, Armed: true})
+	_, code := silent.Switch.On.SetValueRequestFunc(true, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.False(t, panel.Siren())
+	silent.Switch.On.SetValue(true)
+	require.Eventually(t, func() bool {
+		return !silent.Switch.On.Value()
+	}, 2*momentaryReset, 10*time.Millisecond)
+
+	// turning a momentary panic off does not disarm.
+	_, code = silent.Switch.On.SetValueRequestFunc(false, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.True(t, panel.Partition(1).Armed)
+
+	// a firing is not a fire.
+	panel.SetSiren(true)
+	status := testStatus(t, execute)
+	initialize(audible, status)
+	initialize(buttons[2], status)
+	require.True(t, audible.Switch.On.Value())
+	require.False(t, buttons[2].Switch.On.Value())
+
+	_, code = audible.Switch.On.SetValueRequestFunc(false, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.False(t, panel.Partition(1).Armed)
+	require.False(t, panel.Siren())
+
+	_, code = audible.Switch.On.SetValueRequestFunc(true, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.True(t, panel.Siren())
+}
+
+func TestPanicTypes(t *testing.T) {
+	require.Equal(t, []client.PanicType{client.PanicAudible}, Config{
+		PanicTypes: []string{"audible"},
+	}.panicTypes())
+	require.Empty(t, Config{}.panicTypes())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/service"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// PartitionSensor shows whether a partition is firing as a contact sensor, so
+// automations can tell which partition triggered the alarm.
+type PartitionSensor struct {
+	*accessory.A
+	Firing *service.ContactSensor
+
+	number int
+}
+
+func newPartitionSensor(info accessory.Info, number int) *PartitionSensor {
+	a := PartitionSensor{number: number}
+	a.A = accessory.New(info, accessory.TypeSensor)
+
+	a.Firing = service.NewContactSensor()
+	a.AddS(a.Firing.S)
+
+	partitionFiringGauge.WithLabelValues(a.Name()).Set(0)
+
+	return &a
+}
+
+// Handle shows whether the partition is firing.
+func (a *PartitionSensor) Handle(change client.Change, status client.Status) {
+	if change.Kind != client.ChangePartitionFiring || change.Number != a.number {
+		return
+	}
+	partitionFiringGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+	log.Info("partition firing", "partition", change.Number, "status", change.Set)
+	_ = a.Firing.ContactSensorState.SetValue(boolAs[int](change.Set))
+}
+
+func setupPartitions(cfg Config, status client.Status) []*PartitionSensor {
+	var partitions []*PartitionSensor
+	for _, number := range cfg.PartitionSensors {
+		a := newPartitionSensor(accessory.Info{
+			Name:         fmt.Sprintf("Partition %d", number),
+			Manufacturer: manufacturer,
+		}, number)
+		initialize(a, status)
+		a.Id = uint64(400 + number)
+		partitions = append(partitions, a)
+	}
+	return partitions
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupPartitions(t *testing.T) {
+	status := client.Status{Partitions: make([]client.Partition, 16)}
+	for i := range status.Partitions {
+		status.Partitions[i].Number = i
+	}
+	status.Partitions[3].Firing = true
+
+	partitions := setupPartitions(Config{PartitionSensors: []int{3, 0}}, status)
+	require.Len(t, partitions, 2)
+
+	// ids don't depend on the order, so reordering doesn't break homekit.
+	require.Equal(t, uint64(403), partitions[0].Id)
+	require.Equal(t, uint64(400), partitions[1].Id)
+	require.Equal(t, 1, partitions[0].Firing.ContactSensorState.Value())
+	require.Equal(t, 0, partitions[1].Firing.ContactSensorState.Value())
+}
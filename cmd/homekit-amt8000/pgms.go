@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/service"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// PGMSwitch turns a PGM output on and off, or pulses it, e.g. to open a gate.
+type PGMSwitch struct {
+	*accessory.A
+	Switch *service.Switch
+
+	execute Executor
+	pgm     pgmConfig
+	pulse   time.Duration
+}
+
+func newPGMSwitch(info accessory.Info, pgm pgmConfig, pulse time.Duration, execute Executor) *PGMSwitch {
+	a := &PGMSwitch{
+		execute: execute,
+		pgm:     pgm,
+		pulse:   pulse,
+	}
+	a.A = accessory.New(info, accessory.TypeSwitch)
+
+	a.Switch = service.NewSwitch()
+	a.AddS(a.Switch.S)
+	a.Switch.On.SetValueRequestFunc = a.updateHandler
+
+	pgmGauge.WithLabelValues(a.Name()).Set(0)
+
+	return a
+}
+
+func (a *PGMSwitch) updateHandler(
+	value interface{},
+	r *http.Request,
+) (response interface{}, code int) {
+	ctx := requestContext(r)
+	v := value.(bool)
+	if a.pgm.pulse && !v {
+		// the pulse turns it off by itself.
+		return nil, hap.JsonStatusSuccess
+	}
+	log.Info("set pgm", "pgm", a.pgm.number, "on", v, "pulse", a.pgm.pulse)
+	if err := a.set(ctx, v); err != nil {
+		log.Error("could not set pgm", "pgm", a.pgm.number, "err", err)
+		return nil, hapStatus(err)
+	}
+	if a.pgm.pulse {
+		// the session is not held while waiting, and only turning it off is
+		// retried, so a failure never pulses it twice.
+		time.AfterFunc(a.pulse, func() {
+			if err := a.set(context.Background(), false); err != nil {
+				log.Error("could not end pgm pulse", "pgm", a.pgm.number, "err", err)
+				return
+			}
+			a.Switch.On.SetValue(false)
+		})
+	}
+	return nil, hap.JsonStatusSuccess
+}
+
+func (a *PGMSwitch) set(ctx context.Context, on bool) error {
+	return a.execute(ctx, func(cli *client.Client) error {
+		return cli.SetPGMContext(ctx, a.pgm.number, on)
+	})
+}
+
+// Handle shows the PGM state.
+func (a *PGMSwitch) Handle(change client.Change, status client.Status) {
+	if change.Kind != client.ChangePGM || change.Number != a.pgm.number {
+		return
+	}
+	pgmGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+	if a.Switch.On.Value() != change.Set {
+		log.Info("pgm", "pgm", change.Number, "on", change.Set)
+		a.Switch.On.SetValue(change.Set)
+	}
+}
+
+func setupPGMs(execute Executor, cfg Config, status client.Status) []*PGMSwitch {
+	var pgms []*PGMSwitch
+	for _, pgm := range cfg.pgms() {
+		if pgm.number > len(status.PGMs) {
+			log.Warn("alarm system does not have pgm, ignoring it", "pgm", pgm.number)
+			continue
+		}
+		a := newPGMSwitch(accessory.Info{
+			Name:         pgm.name,
+			Manufacturer: manufacturer,
+		}, pgm, cfg.PGMPulse, execute)
+		a.Id = uint64(700 + pgm.number)
+		initialize(a, status)
+		pgms = append(pgms, a)
+	}
+	return pgms
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brutella/hap"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGMConfig(t *testing.T) {
+	cfg := Config{
+		PGMs: []string{"1:Gate:pulse", "2:Garden light", "3", "x:Nope", "4:Nope:toggle", "0"},
+	}
+	require.Equal(t, []pgmConfig{
+		{1, "Gate", true},
+		{2, "Garden light", false},
+		{3, "PGM 3", false},
+	}, cfg.pgms())
+}
+
+func TestPGMSwitch(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetPGM(2, true)
+
+	execute := newTestExecutor(t, panel)
+	pgms := setupPGMs(execute, Config{
+		PGMs:     []string{"1:Gate:pulse", "2:Garden light", "17:Nope"},
+		PGMPulse: time.Millisecond,
+	}, testStatus(t, execute))
+	require.Len(t, pgms, 2)
+	gate, light := pgms[0], pgms[1]
+	require.Equal(t, uint64(701), gate.Id)
+	require.False(t, gate.Switch.On.Value())
+	require.True(t, light.Switch.On.Value())
+
+	_, code := light.Switch.On.SetValueRequestFunc(false, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.False(t, panel.PGM(2))
+
+	_, code = gate.Switch.On.SetValueRequestFunc(true, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.True(t, panel.PGM(1))
+	gate.Switch.On.SetValue(true)
+	require.Eventually(t, func() bool {
+		return !panel.PGM(1) && !gate.Switch.On.Value()
+	}, time.Second, 10*time.Millisecond)
+	cmds := panel.Commands()
+	require.Equal(t, []int{0x4039, 0x4039}, cmds[len(cmds)-2:])
+}
+
+func TestPGMSwitchPulseDoesNotHoldTheSession(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+
+	execute := newTestExecutor(t, panel)
+	pgms := setupPGMs(execute, Config{
+		PGMs:     []string{"1:Gate:pulse"},
+		PGMPulse: time.Hour,
+	}, testStatus(t, execute))
+	require.Len(t, pgms, 1)
+
+	_, code := pgms[0].Switch.On.SetValueRequestFunc(true, nil)
+	require.Equal(t, hap.JsonStatusSuccess, code)
+	require.True(t, panel.PGM(1))
+
+	// other requests go through while the pulse is running.
+	status := testStatus(t, execute)
+	require.True(t, status.PGMs[0].On)
+}
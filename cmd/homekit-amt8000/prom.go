@@ -45,6 +45,102 @@ var bypassedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
 	ConstLabels: map[string]string{},
 }, []string{"name"})
 
+var supervisionLossGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "supervision_loss",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var signalGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "signal_level",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var firingGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "firing",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var firedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "fired",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var partitionFiringGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "partition_firing",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var memoryGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "memory",
+	Help:        "",
+	ConstLabels: map[string]string{},
+})
+
+var troubleGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "trouble",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var supervisionLostGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "supervision_lost",
+	Help:        "",
+	ConstLabels: map[string]string{},
+})
+
+var pgmGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "pgm",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var clockDriftGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "clock_drift_seconds",
+	Help:        "",
+	ConstLabels: map[string]string{},
+})
+
+var blockingGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "arm_blocking",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"name"})
+
+var changeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "alarm",
+	Name:        "changes_total",
+	Help:        "",
+	ConstLabels: map[string]string{},
+}, []string{"kind"})
+
 var requestCounter = promauto.NewCounter(prometheus.CounterOpts{
 	Namespace:   "homekit_amt8000",
 	Subsystem:   "client",
@@ -60,3 +156,11 @@ var requestErrorCounter = promauto.NewCounter(prometheus.CounterOpts{
 	Help:        "",
 	ConstLabels: map[string]string{},
 })
+
+var eventCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace:   "homekit_amt8000",
+	Subsystem:   "client",
+	Name:        "events_total",
+	Help:        "",
+	ConstLabels: map[string]string{},
+})
@@ -14,40 +14,58 @@ type Repeater struct {
 	Connected  *service.ContactSensor
 	LowBattery *characteristic.StatusLowBattery
 	Tamper     *characteristic.StatusTampered
+	Fault      *characteristic.StatusFault
+
+	number int
 }
 
-func newRepeater(info accessory.Info) *Repeater {
-	a := Repeater{}
+func newRepeater(info accessory.Info, number int) *Repeater {
+	a := Repeater{number: number}
 	a.A = accessory.New(info, accessory.TypeSensor)
 
 	a.LowBattery = characteristic.NewStatusLowBattery()
 	a.Tamper = characteristic.NewStatusTampered()
+	a.Fault = characteristic.NewStatusFault()
 
 	a.Connected = service.NewContactSensor()
 	a.Connected.AddC(a.Tamper.C)
 	a.Connected.AddC(a.LowBattery.C)
+	a.Connected.AddC(a.Fault.C)
 	a.AddS(a.Connected.S)
 
+	tamperGauge.WithLabelValues(a.Name()).Set(0)
+	supervisionLossGauge.WithLabelValues(a.Name()).Set(0)
+
 	_ = a.Connected.ContactSensorState.SetValue(0)
 
 	return &a
 }
 
-func (repeater *Repeater) Update(status client.Repeater) {
-	_ = repeater.LowBattery.SetValue(boolAs[int](status.LowBattery))
-	_ = repeater.Tamper.SetValue(boolAs[int](status.Tamper))
-	tamperGauge.WithLabelValues(repeater.Name()).Set(boolAs[float64](status.Tamper))
+// Handle shows the changes of the repeater.
+func (repeater *Repeater) Handle(change client.Change, status client.Status) {
+	if change.Number != repeater.number {
+		return
+	}
+	switch change.Kind {
+	case client.ChangeRepeaterLowBattery:
+		_ = repeater.LowBattery.SetValue(boolAs[int](change.Set))
+	case client.ChangeRepeaterTamper:
+		_ = repeater.Tamper.SetValue(boolAs[int](change.Set))
+		tamperGauge.WithLabelValues(repeater.Name()).Set(boolAs[float64](change.Set))
+	case client.ChangeRepeaterSupervisionLoss:
+		_ = repeater.Fault.SetValue(boolAs[int](change.Set))
+		supervisionLossGauge.WithLabelValues(repeater.Name()).Set(boolAs[float64](change.Set))
+	}
 }
 
 func setupRepeaters(cfg Config, status client.Status) []*Repeater {
 	var repeaters []*Repeater
 	for i, number := range cfg.Repeaters {
-		repeater := status.Repeaters[number-1]
 		a := newRepeater(accessory.Info{
 			Name:         fmt.Sprintf("Repeater %d", number),
 			Manufacturer: manufacturer,
-		})
-		a.Update(repeater)
+		}, number)
+		initialize(a, status)
 		a.Id = uint64(300 + i)
 		repeaters = append(repeaters, a)
 	}
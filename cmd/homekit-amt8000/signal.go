@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// signalLevels reads the signal levels of the wireless devices for as long as
+// the alarm system seems to support it.
+type signalLevels struct {
+	execute     Executor
+	unsupported bool
+}
+
+// read reads the signal levels into the status.
+// The command is not documented, so any failure, including a timeout, is taken
+// as the alarm system not supporting it, and it is not sent again.
+func (s *signalLevels) read(ctx context.Context, status *client.Status) {
+	if s.unsupported {
+		return
+	}
+	if err := s.execute(ctx, func(cli *client.Client) error {
+		return cli.SignalLevelsContext(ctx, status)
+	}); err != nil {
+		log.Warn("signal levels not supported", "err", err)
+		s.unsupported = true
+	}
+}
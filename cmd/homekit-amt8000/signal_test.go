@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalLevels(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetZone(2, amt8000test.Zone{Enabled: true, SignalLevel: 7})
+	session := client.NewSession(panel.Host(), panel.Port(), panel.Password, time.Second, 0)
+	t.Cleanup(func() { _ = session.Close() })
+
+	levels := &signalLevels{execute: session.DoContext}
+	status := testStatus(t, session.DoContext)
+	levels.read(context.Background(), &status)
+	require.False(t, levels.unsupported)
+	require.Equal(t, 7, status.Zones[1].SignalLevel)
+}
+
+func TestSignalLevelsIgnored(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetZone(2, amt8000test.Zone{Enabled: true, SignalLevel: 7})
+	panel.Ignore(0x0b4b)
+	session := client.NewSession(panel.Host(), panel.Port(), panel.Password, 100*time.Millisecond, 0)
+	t.Cleanup(func() { _ = session.Close() })
+
+	levels := &signalLevels{execute: session.DoContext}
+	status := testStatus(t, session.DoContext)
+	levels.read(context.Background(), &status)
+	require.True(t, levels.unsupported)
+	require.Zero(t, status.Zones[1].SignalLevel)
+
+	// not sent again, and the status is still read.
+	status = testStatus(t, session.DoContext)
+	levels.read(context.Background(), &status)
+	require.True(t, status.Zones[1].Enabled)
+	require.Equal(t, 1, countCommand(panel.Commands(), 0x0b4b))
+}
+
+func countCommand(cmds []int, cmd int) int {
+	var n int
+	for _, c := range cmds {
+		if c == cmd {
+			n++
+		}
+	}
+	return n
+}
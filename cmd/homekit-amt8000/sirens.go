@@ -14,38 +14,56 @@ type Siren struct {
 	Connected  *service.ContactSensor
 	LowBattery *characteristic.StatusLowBattery
 	Tamper     *characteristic.StatusTampered
+	Fault      *characteristic.StatusFault
+
+	number int
 }
 
-func newSiren(info accessory.Info) *Siren {
-	a := Siren{}
+func newSiren(info accessory.Info, number int) *Siren {
+	a := Siren{number: number}
 	a.A = accessory.New(info, accessory.TypeSensor)
 
 	a.LowBattery = characteristic.NewStatusLowBattery()
 	a.Tamper = characteristic.NewStatusTampered()
+	a.Fault = characteristic.NewStatusFault()
 
 	a.Connected = service.NewContactSensor()
 	a.Connected.AddC(a.Tamper.C)
 	a.Connected.AddC(a.LowBattery.C)
+	a.Connected.AddC(a.Fault.C)
 	a.AddS(a.Connected.S)
 
+	tamperGauge.WithLabelValues(a.Name()).Set(0)
+	supervisionLossGauge.WithLabelValues(a.Name()).Set(0)
+
 	return &a
 }
 
-func (siren *Siren) Update(status client.Siren) {
-	_ = siren.LowBattery.SetValue(boolAs[int](status.LowBattery))
-	_ = siren.Tamper.SetValue(boolAs[int](status.Tamper))
-	tamperGauge.WithLabelValues(siren.Name()).Set(boolAs[float64](status.Tamper))
+// Handle shows the changes of the siren.
+func (siren *Siren) Handle(change client.Change, status client.Status) {
+	if change.Number != siren.number {
+		return
+	}
+	switch change.Kind {
+	case client.ChangeSirenLowBattery:
+		_ = siren.LowBattery.SetValue(boolAs[int](change.Set))
+	case client.ChangeSirenTamper:
+		_ = siren.Tamper.SetValue(boolAs[int](change.Set))
+		tamperGauge.WithLabelValues(siren.Name()).Set(boolAs[float64](change.Set))
+	case client.ChangeSirenSupervisionLoss:
+		_ = siren.Fault.SetValue(boolAs[int](change.Set))
+		supervisionLossGauge.WithLabelValues(siren.Name()).Set(boolAs[float64](change.Set))
+	}
 }
 
 func setupSirens(cfg Config, status client.Status) []*Siren {
 	var sirens []*Siren
 	for i, number := range cfg.Sirens {
-		siren := status.Sirens[number-1]
 		a := newSiren(accessory.Info{
 			Name:         fmt.Sprintf("Siren %d", number),
 			Manufacturer: manufacturer,
-		})
-		a.Update(siren)
+		}, number)
+		initialize(a, status)
 		a.Id = uint64(200 + i)
 		sirens = append(sirens, a)
 	}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+	"github.com/brutella/hap/service"
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+// TroubleSensor shows a trouble of the alarm system as a contact sensor, open
+// and with a fault while the trouble is happening.
+type TroubleSensor struct {
+	*accessory.A
+	Trouble *service.ContactSensor
+	Fault   *characteristic.StatusFault
+
+	check func(client.Troubles) bool
+}
+
+func newTroubleSensor(info accessory.Info, check func(client.Troubles) bool) *TroubleSensor {
+	a := TroubleSensor{check: check}
+	a.A = accessory.New(info, accessory.TypeSensor)
+
+	a.Fault = characteristic.NewStatusFault()
+
+	a.Trouble = service.NewContactSensor()
+	a.Trouble.AddC(a.Fault.C)
+	a.AddS(a.Trouble.S)
+
+	return &a
+}
+
+// Handle shows whether the trouble is happening.
+func (a *TroubleSensor) Handle(change client.Change, status client.Status) {
+	if _, ok := troubleNames[change.Kind]; !ok {
+		return
+	}
+	trouble := boolAs[int](a.check(status.Troubles))
+	if a.Fault.Value() != trouble {
+		log.Warn("trouble", "name", a.Name(), "status", trouble == 1)
+		_ = a.Fault.SetValue(trouble)
+		_ = a.Trouble.ContactSensorState.SetValue(trouble)
+	}
+}
+
+func setupTroubles(cfg Config, status client.Status) []*TroubleSensor {
+	if !cfg.TroubleSensors {
+		return nil
+	}
+
+	power := newTroubleSensor(accessory.Info{
+		Name:         "AC Power",
+		Manufacturer: manufacturer,
+	}, func(t client.Troubles) bool { return t.ACLoss })
+	power.Id = 500
+
+	communication := newTroubleSensor(accessory.Info{
+		Name:         "Communication",
+		Manufacturer: manufacturer,
+	}, client.Troubles.Communication)
+	communication.Id = 501
+
+	troubles := []*TroubleSensor{power, communication}
+	for _, a := range troubles {
+		initialize(a, status)
+	}
+	return troubles
+}
+
+// troubleNames are the trouble changes, as named in the metrics.
+var troubleNames = map[client.ChangeKind]string{
+	client.ChangeACLoss:      "ac_loss",
+	client.ChangeEthernet:    "ethernet",
+	client.ChangeGPRS:        "gprs",
+	client.ChangePhoneLine:   "phone_line",
+	client.ChangeClockNotSet: "clock_not_set",
+	client.ChangeRFJamming:   "rf_jamming",
+}
+
+// setupTroubleGauges returns the handler that exports the troubles.
+func setupTroubleGauges(status client.Status) changeHandler {
+	for _, name := range troubleNames {
+		troubleGauge.WithLabelValues(name).Set(0)
+	}
+	supervisionLostGauge.Set(0)
+
+	h := changeHandlerFunc(func(change client.Change, status client.Status) {
+		switch change.Kind {
+		case client.ChangeZoneSupervisionLoss,
+			client.ChangeSirenSupervisionLoss,
+			client.ChangeRepeaterSupervisionLoss:
+			supervisionLostGauge.Set(float64(
+				len(status.Troubles.Supervision.Zones) +
+					len(status.Troubles.Supervision.Sirens) +
+					len(status.Troubles.Supervision.Repeaters),
+			))
+		default:
+			if name, ok := troubleNames[change.Kind]; ok {
+				troubleGauge.WithLabelValues(name).Set(boolAs[float64](change.Set))
+			}
+		}
+	})
+	initialize(h, status)
+	return h
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTroubleSensors(t *testing.T) {
+	require.Empty(t, setupTroubles(Config{}, client.Status{}))
+
+	status := client.Status{Troubles: client.Troubles{ACLoss: true}}
+	troubles := setupTroubles(Config{TroubleSensors: true}, status)
+	require.Len(t, troubles, 2)
+	power, communication := troubles[0], troubles[1]
+	require.Equal(t, 1, power.Fault.Value())
+	require.Equal(t, 1, power.Trouble.ContactSensorState.Value())
+	require.Equal(t, 0, communication.Fault.Value())
+
+	for _, a := range troubles {
+		updater(a, status)(client.Status{Troubles: client.Troubles{PhoneLine: true}})
+	}
+	require.Equal(t, 0, power.Fault.Value())
+	require.Equal(t, 0, power.Trouble.ContactSensorState.Value())
+	require.Equal(t, 1, communication.Fault.Value())
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	client "github.com/caarlos0/homekit-amt8000"
+)
+
+const usersUsage = `usage:
+  homekit-amt8000 users list [-all]
+  homekit-amt8000 users set -partitions 1,2 <user> < password
+  homekit-amt8000 users disable <user>`
+
+// usersCommand lists, sets and disables the alarm system users.
+// The password of the user being set is read from r, so it doesn't end up in
+// the shell history nor in the process list.
+func usersCommand(ctx context.Context, cli *client.Client, args []string, r io.Reader, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usersUsage)
+	}
+
+	flags := flag.NewFlagSet("users "+args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	all := flags.Bool("all", false, "")
+	partitions := flags.String("partitions", "", "")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w\n%s", err, usersUsage)
+	}
+
+	switch {
+	case args[0] == "list" && flags.NArg() == 0:
+		return listUsers(ctx, cli, *all, w)
+	case args[0] == "set" && flags.NArg() == 1 && *partitions != "":
+		user, err := strconv.Atoi(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid user: %q", flags.Arg(0))
+		}
+		parts, err := parseInts(*partitions)
+		if err != nil {
+			return fmt.Errorf("invalid partitions: %w", err)
+		}
+		password, err := readPassword(r)
+		if err != nil {
+			return err
+		}
+		if err := cli.SetUserContext(ctx, user, password, parts); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "user %d set\n", user)
+		return err
+	case args[0] == "disable" && flags.NArg() == 1:
+		user, err := strconv.Atoi(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid user: %q", flags.Arg(0))
+		}
+		if err := cli.DisableUserContext(ctx, user); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "user %d disabled\n", user)
+		return err
+	default:
+		return errors.New(usersUsage)
+	}
+}
+
+func listUsers(ctx context.Context, cli *client.Client, all bool, w io.Writer) error {
+	users, err := cli.UsersContext(ctx)
+	if err != nil {
+		return err
+	}
+	names, err := cli.NamesContext(ctx)
+	if err != nil {
+		log.Warn("could not get names from the alarm system", "err", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tNAME\tENABLED\tPARTITIONS")
+	for _, user := range users {
+		if !user.Enabled && !all {
+			continue
+		}
+		parts := make([]string, 0, len(user.Partitions))
+		for _, part := range user.Partitions {
+			parts = append(parts, strconv.Itoa(part))
+		}
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%v\t%s\n",
+			user.Number,
+			names.User(user.Number),
+			user.Enabled,
+			strings.Join(parts, ","),
+		)
+	}
+	return tw.Flush()
+}
+
+// readPassword reads the first line of r.
+func readPassword(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("could not read password: %w", err)
+		}
+		return "", errors.New("could not read password: empty input")
+	}
+	return strings.TrimSpace(scanner.Text()), nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var result []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
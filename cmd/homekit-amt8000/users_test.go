@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsersCommand(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetUser(1, amt8000test.User{Enabled: true, Password: "111111", Partitions: []int{0}})
+	panel.SetUser(2, amt8000test.User{Partitions: []int{1}})
+	panel.SetUserName(1, "Owner")
+
+	cli, err := client.New(panel.Host(), panel.Port(), panel.Password, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Close() })
+
+	runWithInput := func(in string, args ...string) (string, error) {
+		var out bytes.Buffer
+		err := usersCommand(context.Background(), cli, args, strings.NewReader(in), &out)
+		return out.String(), err
+	}
+	run := func(args ...string) (string, error) {
+		return runWithInput("", args...)
+	}
+
+	out, err := run("list")
+	require.NoError(t, err)
+	require.Equal(t, "USER  NAME   ENABLED  PARTITIONS\n1     Owner  true     0\n", out)
+
+	out, err = run("list", "-all")
+	require.NoError(t, err)
+	require.Contains(t, out, "2            false    1\n")
+
+	out, err = runWithInput("654321\n", "set", "-partitions", "1,2", "5")
+	require.NoError(t, err)
+	require.Equal(t, "user 5 set\n", out)
+	require.Equal(t, amt8000test.User{Enabled: true, Password: "654321", Partitions: []int{1, 2}}, panel.User(5))
+
+	_, err = runWithInput("4321\n", "set", "-partitions", "1,2", "5")
+	require.Error(t, err)
+
+	out, err = run("disable", "1")
+	require.NoError(t, err)
+	require.Equal(t, "user 1 disabled\n", out)
+	require.False(t, panel.User(1).Enabled)
+
+	for _, args := range [][]string{
+		{},
+		{"nope"},
+		{"set", "5"},
+		{"set", "-partitions", "1,2", "5"},
+		{"set", "-partitions", "1,2", "5", "654321"},
+		{"set", "-partitions", "x", "5"},
+		{"disable"},
+	} {
+		_, err := run(args...)
+		require.Error(t, err, args)
+	}
+	_, err = run("disable", "99")
+	require.ErrorIs(t, err, client.ErrInvalidUser)
+}
+
+func TestRunCommand(t *testing.T) {
+	err := runCommand(context.Background(), []string{"nope"}, nil)
+	require.EqualError(t, err, `unknown command "nope", available: discover, users`)
+}
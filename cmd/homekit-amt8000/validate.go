@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"golang.org/x/exp/slices"
+)
+
+// validate checks the configuration against how the alarm system is
+// programmed, returning everything that looks wrong.
+func (c Config) validate(prog client.Programming) []string {
+	var problems []string
+	warn := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	zoneAt := func(number int) (client.ZoneProgramming, bool) {
+		if number < 1 || number > len(prog.Zones) {
+			return client.ZoneProgramming{}, false
+		}
+		return prog.Zones[number-1], true
+	}
+
+	modes := []struct {
+		name       string
+		partitions []int
+		native     []int
+	}{
+		{"stay", c.StayPartitions, c.StayNative},
+		{"night", c.NightPartitions, c.NightNative},
+		{"away", c.AwayPartitions, c.AwayNative},
+	}
+	armed := func(partition int) bool {
+		for _, mode := range modes {
+			if slices.Contains(mode.partitions, 0) || slices.Contains(mode.partitions, partition) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, zone := range c.allZones() {
+		prog, ok := zoneAt(zone.number)
+		if !ok {
+			continue
+		}
+		if prog.Function == client.ZoneFunctionDisabled {
+			warn("zone %d is a %s sensor, but it is not programmed", zone.number, zone.kind)
+			continue
+		}
+		switch {
+		case zone.kind == kindMotion && prog.Sensor != client.SensorMotion && prog.Sensor != client.SensorUnknown,
+			zone.kind == kindContact && prog.Sensor != client.SensorContact && prog.Sensor != client.SensorUnknown:
+			warn("zone %d is a %s sensor, but it is programmed as %s", zone.number, zone.kind, prog.Sensor)
+		}
+		if prog.Function != client.ZoneFunction24h && prog.Partition != 0 && !armed(prog.Partition) {
+			warn("zone %d is in partition %d, which no mode arms", zone.number, prog.Partition)
+		}
+	}
+
+	for _, mode := range modes {
+		for _, part := range mode.partitions {
+			if part == 0 {
+				continue
+			}
+			var zones, stay int
+			for _, zone := range prog.Zones {
+				if zone.Function == client.ZoneFunctionDisabled || zone.Partition != part {
+					continue
+				}
+				zones++
+				if zone.Stay {
+					stay++
+				}
+			}
+			if zones == 0 {
+				warn("%s arms partition %d, which has no zones", mode.name, part)
+			} else if stay == 0 && slices.Contains(mode.native, part) {
+				warn("%s arms partition %d in stay mode, but it has no stay zones", mode.name, part)
+			}
+		}
+	}
+
+	return problems
+}
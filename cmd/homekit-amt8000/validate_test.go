@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	prog := client.Programming{Zones: make([]client.ZoneProgramming, 64)}
+	for i := range prog.Zones {
+		prog.Zones[i].Number = i + 1
+	}
+	prog.Zones[0] = client.ZoneProgramming{Number: 1, Partition: 1, Function: client.ZoneFunctionDelayed, Sensor: client.SensorContact}
+	prog.Zones[1] = client.ZoneProgramming{Number: 2, Partition: 2, Function: client.ZoneFunctionInstant, Sensor: client.SensorContact}
+	prog.Zones[2] = client.ZoneProgramming{Number: 3, Partition: 3, Function: client.ZoneFunctionInstant, Sensor: client.SensorMotion}
+	prog.Zones[3] = client.ZoneProgramming{Number: 4, Partition: 1, Function: client.ZoneFunction24h, Sensor: client.SensorUnknown}
+	prog.Zones[4] = client.ZoneProgramming{Number: 5, Partition: 2, Function: client.ZoneFunctionFollower, Sensor: client.SensorMotion, Stay: true}
+
+	t.Run("valid", func(t *testing.T) {
+		require.Empty(t, Config{
+			ContactZones:    []int{1, 2},
+			MotionZones:     []int{3, 4, 5},
+			StayPartitions:  []int{2},
+			StayNative:      []int{2},
+			NightPartitions: []int{1, 2},
+			AwayPartitions:  []int{0},
+		}.validate(prog))
+	})
+
+	t.Run("mismatches", func(t *testing.T) {
+		require.Equal(t, []string{
+			"zone 2 is a motion sensor, but it is programmed as contact",
+			"zone 3 is in partition 3, which no mode arms",
+			"zone 6 is a contact sensor, but it is not programmed",
+			"night arms partition 4, which has no zones",
+			"away arms partition 1 in stay mode, but it has no stay zones",
+		}, Config{
+			ContactZones:    []int{1, 6},
+			MotionZones:     []int{2, 3},
+			StayPartitions:  []int{2},
+			NightPartitions: []int{1, 4},
+			AwayPartitions:  []int{1, 2},
+			AwayNative:      []int{1},
+		}.validate(prog))
+	})
+}
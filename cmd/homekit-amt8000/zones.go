@@ -2,12 +2,14 @@ package main
 
 import (
 	"net/http"
+	"sync/atomic"
 
 	"github.com/brutella/hap"
 	"github.com/brutella/hap/accessory"
 	"github.com/brutella/hap/characteristic"
 	"github.com/brutella/hap/service"
 	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type AlarmSensor struct {
@@ -17,9 +19,19 @@ type AlarmSensor struct {
 	Bypass     *service.Switch
 	LowBattery *characteristic.StatusLowBattery
 	Tamper     *characteristic.StatusTampered
+	Fault      *characteristic.StatusFault
 
 	execute Executor
 	zone    zoneConfig
+
+	// there are no characteristics for these, so they are kept here for the
+	// web page.
+	firing atomic.Bool
+	fired  atomic.Bool
+
+	// the fault is shown when the zone lost supervision or prevented arming.
+	unsupervised atomic.Bool
+	blocking     atomic.Bool
 }
 
 func newAlarmSensor(info accessory.Info, zone zoneConfig, execute Executor) *AlarmSensor {
@@ -31,17 +43,20 @@ func newAlarmSensor(info accessory.Info, zone zoneConfig, execute Executor) *Ala
 
 	a.LowBattery = characteristic.NewStatusLowBattery()
 	a.Tamper = characteristic.NewStatusTampered()
+	a.Fault = characteristic.NewStatusFault()
 
 	switch zone.kind {
 	case kindContact:
 		a.Contact = service.NewContactSensor()
 		a.Contact.AddC(a.Tamper.C)
 		a.Contact.AddC(a.LowBattery.C)
+		a.Contact.AddC(a.Fault.C)
 		a.AddS(a.Contact.S)
 	case kindMotion:
 		a.Motion = service.NewMotionSensor()
 		a.Motion.AddC(a.LowBattery.C)
 		a.Motion.AddC(a.Tamper.C)
+		a.Motion.AddC(a.Fault.C)
 		a.AddS(a.Motion.S)
 	}
 
@@ -49,6 +64,20 @@ func newAlarmSensor(info accessory.Info, zone zoneConfig, execute Executor) *Ala
 		a.Bypass = service.NewSwitch()
 		a.AddS(a.Bypass.S)
 		a.Bypass.On.SetValueRequestFunc = a.updateHandler
+		// not bypassed, as in an empty status.
+		a.Bypass.On.SetValue(true)
+	}
+
+	for _, gauge := range []*prometheus.GaugeVec{
+		openGauge,
+		violatedGauge,
+		tamperGauge,
+		bypassedGauge,
+		firingGauge,
+		firedGauge,
+		supervisionLossGauge,
+	} {
+		gauge.WithLabelValues(a.Name()).Set(0)
 	}
 
 	return a
@@ -56,47 +85,68 @@ func newAlarmSensor(info accessory.Info, zone zoneConfig, execute Executor) *Ala
 
 func (a *AlarmSensor) updateHandler(
 	value interface{},
-	_ *http.Request,
+	r *http.Request,
 ) (response interface{}, code int) {
+	ctx := requestContext(r)
 	// we bypass the zone when the switch is ON
 	v := !value.(bool)
 	log.Info("set zone bypass", "zone", a.zone.number, "bypass", v)
-	if err := a.execute(func(cli *client.Client) error {
-		return cli.Bypass(a.zone.number, v)
+	if err := a.execute(ctx, func(cli *client.Client) error {
+		return cli.BypassContext(ctx, a.zone.number, v)
 	}); err != nil {
 		log.Error("failed to set bypass", "zone", a.zone.number, "value", v, "err", err)
-		return nil, hap.JsonStatusResourceBusy
+		return nil, hapStatus(err)
 	}
 	return nil, hap.JsonStatusSuccess
 }
 
-func (a *AlarmSensor) Update(zone client.Zone) {
-	openGauge.WithLabelValues(a.Name()).Set(boolAs[float64](zone.Open))
-	violatedGauge.WithLabelValues(a.Name()).Set(boolAs[float64](zone.Violated))
-	tamperGauge.WithLabelValues(a.Name()).Set(boolAs[float64](zone.Tamper))
-	bypassedGauge.WithLabelValues(a.Name()).Set(boolAs[float64](zone.Anulated))
-
-	batlvl := boolAs[int](zone.LowBattery)
-	if a.LowBattery.Value() != batlvl {
-		log.Info("low battery", "zone", zone.Number, "status", zone.LowBattery)
-		_ = a.LowBattery.SetValue(batlvl)
-	}
-
-	tamper := boolAs[int](zone.Tamper)
-	if a.Tamper.Value() != tamper {
-		log.Info("tamper", "zone", zone.Number, "status", zone.Tamper)
-		_ = a.Tamper.SetValue(tamper)
+// Handle shows the changes of the zone.
+func (a *AlarmSensor) Handle(change client.Change, status client.Status) {
+	if change.Number != a.zone.number {
+		return
 	}
-
-	bypassing := zone.Anulated
-	if a.zone.allowBypass && a.Bypass.On.Value() == bypassing {
-		log.Info("bypass", "zone", zone.Number, "status", bypassing)
-		a.Bypass.On.SetValue(!bypassing)
+	switch change.Kind {
+	case client.ChangeZoneOpen:
+		openGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		a.updateOpen(status.Zones[change.Number-1])
+	case client.ChangeZoneViolated:
+		violatedGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		a.updateOpen(status.Zones[change.Number-1])
+	case client.ChangeZoneFiring:
+		firingGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		a.firing.Store(change.Set)
+		a.updateOpen(status.Zones[change.Number-1])
+	case client.ChangeZoneFired:
+		firedGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		a.fired.Store(change.Set)
+	case client.ChangeZoneSupervisionLoss:
+		supervisionLossGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		log.Info("supervision loss", "zone", change.Number, "status", change.Set)
+		a.unsupervised.Store(change.Set)
+		a.updateFault()
+	case client.ChangeZoneLowBattery:
+		log.Info("low battery", "zone", change.Number, "status", change.Set)
+		_ = a.LowBattery.SetValue(boolAs[int](change.Set))
+	case client.ChangeZoneTamper:
+		tamperGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		log.Info("tamper", "zone", change.Number, "status", change.Set)
+		_ = a.Tamper.SetValue(boolAs[int](change.Set))
+	case client.ChangeZoneBypassed:
+		bypassedGauge.WithLabelValues(a.Name()).Set(boolAs[float64](change.Set))
+		if a.zone.allowBypass {
+			log.Info("bypass", "zone", change.Number, "status", change.Set)
+			a.Bypass.On.SetValue(!change.Set)
+		}
 	}
+}
 
+// updateOpen shows the zone as open while it is open or violated.
+// A firing zone is shown as open too, so it is clear which one triggered the
+// alarm.
+func (a *AlarmSensor) updateOpen(zone client.Zone) {
 	switch a.zone.kind {
 	case kindContact:
-		current := boolAs[int](zone.IsOpen())
+		current := boolAs[int](zone.IsOpen() || zone.Firing)
 		if v := a.Contact.ContactSensorState.Value(); v == current {
 			return
 		}
@@ -106,9 +156,10 @@ func (a *AlarmSensor) Update(zone client.Zone) {
 			"zone", zone.Number,
 			"open", zone.Open,
 			"violated", zone.Violated,
+			"firing", zone.Firing,
 		)
 	case kindMotion:
-		current := zone.IsOpen()
+		current := zone.IsOpen() || zone.Firing
 		if v := a.Motion.MotionDetected.Value(); v == current {
 			return
 		}
@@ -118,10 +169,29 @@ func (a *AlarmSensor) Update(zone client.Zone) {
 			"zone", zone.Number,
 			"open", zone.Open,
 			"violated", zone.Violated,
+			"firing", zone.Firing,
 		)
 	}
 }
 
+// SetBlocking shows a fault on the zone if it prevented arming, and fault
+// showing is enabled.
+func (a *AlarmSensor) SetBlocking(blocking bool) {
+	if !a.zone.showFault {
+		return
+	}
+	a.blocking.Store(blocking)
+	a.updateFault()
+}
+
+func (a *AlarmSensor) updateFault() {
+	fault := boolAs[int](a.unsupervised.Load() || a.blocking.Load())
+	if a.Fault.Value() != fault {
+		log.Info("fault", "zone", a.zone.number, "status", fault == 1)
+		_ = a.Fault.SetValue(fault)
+	}
+}
+
 func setupZones(
 	execute Executor,
 	cfg Config,
@@ -135,7 +205,7 @@ func setupZones(
 			Manufacturer: manufacturer,
 		}, zone, execute)
 		a.Id = uint64(100 + zone.number)
-		a.Update(status.Zones[zone.number])
+		initialize(a, status)
 		sensors = append(sensors, a)
 	}
 	return sensors
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+	client "github.com/caarlos0/homekit-amt8000"
+	"github.com/caarlos0/homekit-amt8000/amt8000test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlarmSensorBypass(t *testing.T) {
+	panel := amt8000test.NewPanel("123456")
+	t.Cleanup(panel.Close)
+	panel.SetZone(5, amt8000test.Zone{Enabled: true, Partition: 1})
+
+	zone := zoneConfig{number: 5, name: "Door", kind: kindContact, allowBypass: true}
+	sensor := newAlarmSensor(accessory.Info{Name: zone.name}, zone, newTestExecutor(t, panel))
+
+	t.Run("armed", func(t *testing.T) {
+		panel.SetPartition(1, amt8000test.Partition{Enabled: true, Armed: true})
+		t.Cleanup(func() { panel.SetPartition(1, amt8000test.Partition{Enabled: true}) })
+
+		_, code := sensor.updateHandler(false, nil)
+		require.Equal(t, hap.JsonStatusInsufficientPrivileges, code)
+		require.False(t, panel.Zone(5).Bypassed)
+	})
+
+	t.Run("disarmed", func(t *testing.T) {
+		_, code := sensor.updateHandler(false, nil)
+		require.Equal(t, hap.JsonStatusSuccess, code)
+		require.True(t, panel.Zone(5).Bypassed)
+	})
+}
+
+// zoneStatus returns a status with the given zone 5.
+func zoneStatus(zone client.Zone) client.Status {
+	zones := make([]client.Zone, 5)
+	zones[4] = zone
+	return client.Status{Zones: zones}
+}
+
+func TestAlarmSensorFiring(t *testing.T) {
+	zone := zoneConfig{number: 5, name: "Door", kind: kindContact}
+	sensor := newAlarmSensor(accessory.Info{Name: zone.name}, zone, nil)
+	update := updater(sensor, client.Status{})
+
+	update(zoneStatus(client.Zone{Number: 5, Enabled: true, Firing: true, Fired: true}))
+	require.Equal(t, 1, sensor.Contact.ContactSensorState.Value())
+	require.True(t, sensor.firing.Load())
+	require.True(t, sensor.fired.Load())
+
+	update(zoneStatus(client.Zone{Number: 5, Enabled: true, Fired: true}))
+	require.Equal(t, 0, sensor.Contact.ContactSensorState.Value())
+	require.False(t, sensor.firing.Load())
+	require.True(t, sensor.fired.Load())
+}
+
+func TestAlarmSensorFault(t *testing.T) {
+	zone := zoneConfig{number: 5, name: "Door", kind: kindMotion, showFault: true}
+	sensor := newAlarmSensor(accessory.Info{Name: zone.name}, zone, nil)
+	update := updater(sensor, client.Status{})
+
+	update(zoneStatus(client.Zone{Number: 5, Enabled: true, SignalLevel: 8}))
+	require.Equal(t, 0, sensor.Fault.Value())
+
+	update(zoneStatus(client.Zone{Number: 5, Enabled: true, SupervisionLoss: true}))
+	require.Equal(t, 1, sensor.Fault.Value())
+
+	sensor.SetBlocking(true)
+	update(zoneStatus(client.Zone{Number: 5, Enabled: true}))
+	require.Equal(t, 1, sensor.Fault.Value())
+
+	sensor.SetBlocking(false)
+	require.Equal(t, 0, sensor.Fault.Value())
+}
@@ -0,0 +1,104 @@
+package contactid
+
+import "fmt"
+
+// Code is a Contact ID event code.
+type Code int
+
+// Some of the most common event codes.
+const (
+	CodeMedical         Code = 100
+	CodeFire            Code = 110
+	CodePanic           Code = 120
+	CodeDuress          Code = 121
+	CodeSilentPanic     Code = 122
+	CodeAudiblePanic    Code = 123
+	CodeBurglary        Code = 130
+	CodePerimeter       Code = 131
+	CodeInterior        Code = 132
+	Code24Hour          Code = 133
+	CodeEntryExit       Code = 134
+	CodeTamper          Code = 137
+	CodeExpansionTamper Code = 145
+	CodeACLoss          Code = 301
+	CodeLowBattery      Code = 302
+	CodeBatteryMissing  Code = 311
+	CodeBellTrouble     Code = 321
+	CodeRFJamming       Code = 344
+	CodePhoneLine       Code = 351
+	CodeCommFailure     Code = 354
+	CodeSupervisionLoss Code = 381
+	CodeSensorTamper    Code = 383
+	CodeRFLowBattery    Code = 384
+	CodeOpenClose       Code = 401
+	CodeCancel          Code = 406
+	CodeRemoteOpenClose Code = 407
+	CodeQuickArm        Code = 408
+	CodeKeyswitch       Code = 409
+	CodeBypass          Code = 570
+	CodeTimeReset       Code = 625
+	CodeTest            Code = 602
+)
+
+var descriptions = map[Code]string{
+	CodeMedical:         "Medical emergency",
+	CodeFire:            "Fire",
+	CodePanic:           "Panic",
+	CodeDuress:          "Duress",
+	CodeSilentPanic:     "Silent panic",
+	CodeAudiblePanic:    "Audible panic",
+	CodeBurglary:        "Burglary",
+	CodePerimeter:       "Perimeter burglary",
+	CodeInterior:        "Interior burglary",
+	Code24Hour:          "24 hour zone burglary",
+	CodeEntryExit:       "Entry/exit burglary",
+	CodeTamper:          "Tamper",
+	CodeExpansionTamper: "Expansion module tamper",
+	CodeACLoss:          "AC power loss",
+	CodeLowBattery:      "Low system battery",
+	CodeBatteryMissing:  "Battery missing or dead",
+	CodeBellTrouble:     "Siren trouble",
+	CodeRFJamming:       "RF jamming",
+	CodePhoneLine:       "Phone line failure",
+	CodeCommFailure:     "Communication failure",
+	CodeSupervisionLoss: "Wireless supervision loss",
+	CodeSensorTamper:    "Sensor tamper",
+	CodeRFLowBattery:    "Wireless sensor low battery",
+	CodeOpenClose:       "Open/close by user",
+	CodeCancel:          "Cancel",
+	CodeRemoteOpenClose: "Remote open/close",
+	CodeQuickArm:        "Quick arm",
+	CodeKeyswitch:       "Keyswitch open/close",
+	CodeBypass:          "Zone bypass",
+	CodeTimeReset:       "Time/date reset",
+	CodeTest:            "Periodic test report",
+}
+
+// String returns a human readable description of the code, e.g. "Burglary".
+func (c Code) String() string {
+	if desc, ok := descriptions[c]; ok {
+		return desc
+	}
+	return fmt.Sprintf("Event %03d", int(c))
+}
+
+// IsOpenClose tells whether the code is about arming (close) or disarming
+// (open) the alarm system.
+func (c Code) IsOpenClose() bool {
+	return c >= 400 && c < 410
+}
+
+func (c Code) describe(q Qualifier) string {
+	if c.IsOpenClose() {
+		switch q {
+		case QualifierNew:
+			return "Disarmed: " + c.String()
+		case QualifierRestore:
+			return "Armed: " + c.String()
+		}
+	}
+	if q == QualifierRestore {
+		return c.String() + " restore"
+	}
+	return c.String()
+}
@@ -0,0 +1,205 @@
+// Package contactid parses and formats Contact ID (SIA DC-05) messages, used
+// by the alarm system in both its event notifications and event log.
+package contactid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidLength   = errors.New("invalid message length")
+	ErrInvalidDigit    = errors.New("invalid digit")
+	ErrInvalidChecksum = errors.New("invalid checksum")
+)
+
+// Qualifier tells whether an event is new, a restore of a previous event, or
+// a status report.
+type Qualifier int
+
+const (
+	QualifierNew     Qualifier = 1
+	QualifierRestore Qualifier = 3
+	QualifierStatus  Qualifier = 6
+)
+
+func (q Qualifier) String() string {
+	switch q {
+	case QualifierNew:
+		return "new"
+	case QualifierRestore:
+		return "restore"
+	case QualifierStatus:
+		return "status"
+	default:
+		return "unknown"
+	}
+}
+
+// messageType is the Contact ID message type, 18 being the preferred one.
+const messageType = 18
+
+// Message is a Contact ID message.
+type Message struct {
+	Account   int
+	Qualifier Qualifier
+	Code      Code
+	Partition int
+
+	// Either the zone or the user number, depending on the event code.
+	Zone int
+}
+
+// Restore tells whether this is a restore of a previous event.
+func (m Message) Restore() bool {
+	return m.Qualifier == QualifierRestore
+}
+
+// Description returns a human readable description of the message, taking
+// the qualifier into account, e.g. "Burglary restore".
+func (m Message) Description() string {
+	return m.Code.describe(m.Qualifier)
+}
+
+// String formats the message the way it is usually written, without the
+// checksum, e.g. "1234 18 1130 01 015".
+func (m Message) String() string {
+	return fmt.Sprintf(
+		"%04d %02d %d%03d %02d %03d",
+		m.Account, messageType, m.Qualifier, m.Code, m.Partition, m.Zone,
+	)
+}
+
+// Format formats the message as the 16 digits sent over the wire, including
+// its checksum.
+func (m Message) Format() string {
+	digits := fmt.Sprintf(
+		"%04d%02d%d%03d%02d%03d",
+		m.Account, messageType, m.Qualifier, m.Code, m.Partition, m.Zone,
+	)
+	return digits + checksumDigit(digits)
+}
+
+// Parse parses a Contact ID message as sent over the wire:
+//
+//	ACCT MT Q EEE GG CCC S
+//
+// Spaces are ignored.
+// The checksum digit is optional, but is validated if present.
+func Parse(s string) (Message, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) != 15 && len(s) != 16 {
+		return Message{}, fmt.Errorf("%w: %d", ErrInvalidLength, len(s))
+	}
+	for _, r := range s[:15] {
+		if r < '0' || r > '9' {
+			return Message{}, fmt.Errorf("%w: %q", ErrInvalidDigit, r)
+		}
+	}
+	if len(s) == 16 && !strings.EqualFold(checksumDigit(s[:15]), s[15:]) {
+		return Message{}, fmt.Errorf("%w: %s", ErrInvalidChecksum, s)
+	}
+
+	return Message{
+		Account:   atoi(s[0:4]),
+		Qualifier: Qualifier(atoi(s[6:7])),
+		Code:      Code(atoi(s[7:10])),
+		Partition: atoi(s[10:12]),
+		Zone:      atoi(s[12:15]),
+	}, nil
+}
+
+// Decode decodes a message as sent by the alarm system over ISECNet: digits,
+// one per byte, without the message type and checksum:
+//
+//	account (4) | qualifier (1) | code (3) | partition (2) | zone (3)
+func Decode(buf []byte) (Message, error) {
+	if len(buf) != 13 {
+		return Message{}, fmt.Errorf("%w: %d", ErrInvalidLength, len(buf))
+	}
+	var fields [5]int
+	for i, part := range [][]byte{
+		buf[0:4],
+		buf[4:5],
+		buf[5:8],
+		buf[8:10],
+		buf[10:13],
+	} {
+		n, err := DecodeDigits(part)
+		if err != nil {
+			return Message{}, err
+		}
+		fields[i] = n
+	}
+	return Message{
+		Account:   fields[0],
+		Qualifier: Qualifier(fields[1]),
+		Code:      Code(fields[2]),
+		Partition: fields[3],
+		Zone:      fields[4],
+	}, nil
+}
+
+// Encode encodes the message the same way Decode expects it.
+func (m Message) Encode() []byte {
+	buf := EncodeDigits(m.Account, 4)
+	buf = append(buf, EncodeDigits(int(m.Qualifier), 1)...)
+	buf = append(buf, EncodeDigits(int(m.Code), 3)...)
+	buf = append(buf, EncodeDigits(m.Partition, 2)...)
+	buf = append(buf, EncodeDigits(m.Zone, 3)...)
+	return buf
+}
+
+// EncodeDigits encodes n as size digits, one per byte, with 0 as 0x0a.
+func EncodeDigits(n, size int) []byte {
+	buf := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		digit := byte(n % 10)
+		if digit == 0 {
+			digit = 0x0a
+		}
+		buf[i] = digit
+		n /= 10
+	}
+	return buf
+}
+
+// DecodeDigits decodes digits encoded by EncodeDigits into a number.
+func DecodeDigits(buf []byte) (int, error) {
+	var n int
+	for _, digit := range buf {
+		switch {
+		case digit == 0x0a:
+			digit = 0
+		case digit > 0x09:
+			return 0, fmt.Errorf("%w: %#02x", ErrInvalidDigit, digit)
+		}
+		n = n*10 + int(digit)
+	}
+	return n, nil
+}
+
+// checksumDigit returns the digit that makes the sum of all digits a multiple
+// of 15, zeros counting as 10.
+func checksumDigit(digits string) string {
+	var sum int
+	for _, r := range digits {
+		d := int(r - '0')
+		if d == 0 {
+			d = 10
+		}
+		sum += d
+	}
+	// from 1 to 15, with 10 being 0 and anything above it written in hex.
+	check := 15 - sum%15
+	return string("1234567890BCDEF"[check-1])
+}
+
+func atoi(s string) int {
+	var n int
+	for _, r := range s {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
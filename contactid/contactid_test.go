@@ -0,0 +1,103 @@
+package contactid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	msg := Message{
+		Account:   1234,
+		Qualifier: QualifierNew,
+		Code:      CodeBurglary,
+		Partition: 1,
+		Zone:      15,
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		require.Equal(t, "1234 18 1130 01 015", msg.String())
+		require.Equal(t, "123418113001015E", msg.Format())
+
+		got, err := Parse(msg.Format())
+		require.NoError(t, err)
+		require.Equal(t, msg, got)
+	})
+
+	t.Run("spaces and no checksum", func(t *testing.T) {
+		got, err := Parse(msg.String())
+		require.NoError(t, err)
+		require.Equal(t, msg, got)
+	})
+
+	t.Run("hex checksum", func(t *testing.T) {
+		msg := Message{Account: 1111, Qualifier: QualifierNew, Code: 111, Partition: 11, Zone: 128}
+		require.Equal(t, "111118111111128F", msg.Format())
+		got, err := Parse("111118111111128f")
+		require.NoError(t, err)
+		require.Equal(t, msg, got)
+	})
+
+	t.Run("invalid checksum", func(t *testing.T) {
+		_, err := Parse("1234181130010151")
+		require.ErrorIs(t, err, ErrInvalidChecksum)
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := Parse("12341811300101")
+		require.ErrorIs(t, err, ErrInvalidLength)
+	})
+
+	t.Run("invalid digit", func(t *testing.T) {
+		_, err := Parse("12341811300A015")
+		require.ErrorIs(t, err, ErrInvalidDigit)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	msg := Message{
+		Account:   1234,
+		Qualifier: QualifierRestore,
+		Code:      CodeACLoss,
+		Partition: 0,
+		Zone:      0,
+	}
+	buf := msg.Encode()
+	require.Equal(t, []byte{
+		0x01, 0x02, 0x03, 0x04,
+		0x03,
+		0x03, 0x0a, 0x01,
+		0x0a, 0x0a,
+		0x0a, 0x0a, 0x0a,
+	}, buf)
+
+	got, err := Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, got)
+	require.True(t, got.Restore())
+
+	_, err = Decode(buf[:5])
+	require.ErrorIs(t, err, ErrInvalidLength)
+
+	buf[0] = 0x0f
+	_, err = Decode(buf)
+	require.ErrorIs(t, err, ErrInvalidDigit)
+}
+
+func TestDescription(t *testing.T) {
+	for msg, desc := range map[Message]string{
+		{Qualifier: QualifierNew, Code: CodeBurglary}:      "Burglary",
+		{Qualifier: QualifierRestore, Code: CodeBurglary}:  "Burglary restore",
+		{Qualifier: QualifierNew, Code: CodeACLoss}:        "AC power loss",
+		{Qualifier: QualifierRestore, Code: CodeACLoss}:    "AC power loss restore",
+		{Qualifier: QualifierNew, Code: CodeOpenClose}:     "Disarmed: Open/close by user",
+		{Qualifier: QualifierRestore, Code: CodeOpenClose}: "Armed: Open/close by user",
+		{Qualifier: QualifierNew, Code: CodeSensorTamper}:  "Sensor tamper",
+		{Qualifier: QualifierStatus, Code: CodeTest}:       "Periodic test report",
+		{Qualifier: QualifierNew, Code: 999}:               "Event 999",
+	} {
+		t.Run(desc, func(t *testing.T) {
+			require.Equal(t, desc, msg.Description())
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package amt8000
+
+import "fmt"
+
+// ChangeKind is what changed between two statuses.
+type ChangeKind int
+
+const (
+	ChangeState ChangeKind = iota + 1
+	ChangeSiren
+	ChangeTamper
+	ChangeBattery
+	ChangeACLoss
+	ChangeEthernet
+	ChangeGPRS
+	ChangePhoneLine
+	ChangeClockNotSet
+	ChangeRFJamming
+	ChangeZoneOpen
+	ChangeZoneViolated
+	ChangeZoneBypassed
+	ChangeZoneTamper
+	ChangeZoneLowBattery
+	ChangeZoneFiring
+	ChangeZoneFired
+	ChangeZoneSupervisionLoss
+	ChangePartitionArmed
+	ChangePartitionStay
+	ChangePartitionFiring
+	ChangePartitionFired
+	ChangePartitionExitDelay
+	ChangePartitionEntryDelay
+	ChangeSirenTamper
+	ChangeSirenLowBattery
+	ChangeSirenSupervisionLoss
+	ChangeRepeaterTamper
+	ChangeRepeaterLowBattery
+	ChangeRepeaterSupervisionLoss
+	ChangePGM
+)
+
+// parts returns what the change is about, e.g. "zone", and the attribute that
+// changed, e.g. "open".
+func (k ChangeKind) parts() (string, string) {
+	switch k {
+	case ChangeState:
+		return "system", "state"
+	case ChangeSiren:
+		return "system", "siren"
+	case ChangeTamper:
+		return "system", "tamper"
+	case ChangeBattery:
+		return "system", "battery"
+	case ChangeACLoss:
+		return "system", "ac loss"
+	case ChangeEthernet:
+		return "system", "ethernet failure"
+	case ChangeGPRS:
+		return "system", "gprs failure"
+	case ChangePhoneLine:
+		return "system", "phone line failure"
+	case ChangeClockNotSet:
+		return "system", "clock not set"
+	case ChangeRFJamming:
+		return "system", "rf jamming"
+	case ChangeZoneOpen:
+		return "zone", "open"
+	case ChangeZoneViolated:
+		return "zone", "violated"
+	case ChangeZoneBypassed:
+		return "zone", "bypassed"
+	case ChangeZoneTamper:
+		return "zone", "tamper"
+	case ChangeZoneLowBattery:
+		return "zone", "low battery"
+	case ChangeZoneFiring:
+		return "zone", "firing"
+	case ChangeZoneFired:
+		return "zone", "fired"
+	case ChangeZoneSupervisionLoss:
+		return "zone", "supervision loss"
+	case ChangePartitionArmed:
+		return "partition", "armed"
+	case ChangePartitionStay:
+		return "partition", "stay"
+	case ChangePartitionFiring:
+		return "partition", "firing"
+	case ChangePartitionFired:
+		return "partition", "fired"
+	case ChangePartitionExitDelay:
+		return "partition", "exit delay"
+	case ChangePartitionEntryDelay:
+		return "partition", "entry delay"
+	case ChangeSirenTamper:
+		return "siren", "tamper"
+	case ChangeSirenLowBattery:
+		return "siren", "low battery"
+	case ChangeSirenSupervisionLoss:
+		return "siren", "supervision loss"
+	case ChangeRepeaterTamper:
+		return "repeater", "tamper"
+	case ChangeRepeaterLowBattery:
+		return "repeater", "low battery"
+	case ChangeRepeaterSupervisionLoss:
+		return "repeater", "supervision loss"
+	case ChangePGM:
+		return "pgm", "on"
+	default:
+		return "unknown", "unknown"
+	}
+}
+
+func (k ChangeKind) String() string {
+	subject, attr := k.parts()
+	return subject + " " + attr
+}
+
+// Change is a single difference between two statuses.
+type Change struct {
+	Kind ChangeKind
+
+	// Number of the zone, partition, siren, repeater or PGM, as in their Number
+	// field. Always 0 for system wide changes.
+	Number int
+
+	// Whether the attribute is now set, e.g. the zone is now open.
+	// ChangeState and ChangeBattery are not booleans, so it is always false
+	// for them: their values are in the fields below instead.
+	Set bool
+
+	// The previous and new alarm system state, only set for ChangeState.
+	StateFrom, StateTo State
+
+	// The previous and new battery status, only set for ChangeBattery.
+	BatteryFrom, BatteryTo BatteryStatus
+}
+
+func (c Change) String() string {
+	subject, attr := c.Kind.parts()
+	if subject != "system" {
+		subject = fmt.Sprintf("%s %d", subject, c.Number)
+	}
+	switch c.Kind {
+	case ChangeState:
+		return fmt.Sprintf("%s %s: %v -> %v", subject, attr, c.StateFrom, c.StateTo)
+	case ChangeBattery:
+		return fmt.Sprintf("%s %s: %v -> %v", subject, attr, c.BatteryFrom, c.BatteryTo)
+	default:
+		return fmt.Sprintf("%s %s: %v", subject, attr, c.Set)
+	}
+}
+
+// Diff returns everything that changed from prev to s, system wide changes
+// first, then zones, partitions, sirens, repeaters and PGMs, in order.
+// Diffing against an empty Status returns everything that is set, which is
+// handy to handle the first status read.
+func (s Status) Diff(prev Status) []Change {
+	var changes []Change
+	add := func(kind ChangeKind, number int, was, is bool) {
+		if was != is {
+			changes = append(changes, Change{Kind: kind, Number: number, Set: is})
+		}
+	}
+
+	if s.State != prev.State {
+		changes = append(changes, Change{Kind: ChangeState, StateFrom: prev.State, StateTo: s.State})
+	}
+	add(ChangeSiren, 0, prev.Siren, s.Siren)
+	add(ChangeTamper, 0, prev.Tamper, s.Tamper)
+	if s.Battery != prev.Battery {
+		changes = append(changes, Change{Kind: ChangeBattery, BatteryFrom: prev.Battery, BatteryTo: s.Battery})
+	}
+	add(ChangeACLoss, 0, prev.Troubles.ACLoss, s.Troubles.ACLoss)
+	add(ChangeEthernet, 0, prev.Troubles.Ethernet, s.Troubles.Ethernet)
+	add(ChangeGPRS, 0, prev.Troubles.GPRS, s.Troubles.GPRS)
+	add(ChangePhoneLine, 0, prev.Troubles.PhoneLine, s.Troubles.PhoneLine)
+	add(ChangeClockNotSet, 0, prev.Troubles.ClockNotSet, s.Troubles.ClockNotSet)
+	add(ChangeRFJamming, 0, prev.Troubles.RFJamming, s.Troubles.RFJamming)
+
+	for i, zone := range s.Zones {
+		var old Zone
+		if i < len(prev.Zones) {
+			old = prev.Zones[i]
+		}
+		add(ChangeZoneOpen, zone.Number, old.Open, zone.Open)
+		add(ChangeZoneViolated, zone.Number, old.Violated, zone.Violated)
+		add(ChangeZoneBypassed, zone.Number, old.Anulated, zone.Anulated)
+		add(ChangeZoneTamper, zone.Number, old.Tamper, zone.Tamper)
+		add(ChangeZoneLowBattery, zone.Number, old.LowBattery, zone.LowBattery)
+		add(ChangeZoneFiring, zone.Number, old.Firing, zone.Firing)
+		add(ChangeZoneFired, zone.Number, old.Fired, zone.Fired)
+		add(ChangeZoneSupervisionLoss, zone.Number, old.SupervisionLoss, zone.SupervisionLoss)
+	}
+
+	for i, part := range s.Partitions {
+		var old Partition
+		if i < len(prev.Partitions) {
+			old = prev.Partitions[i]
+		}
+		add(ChangePartitionArmed, part.Number, old.Armed, part.Armed)
+		add(ChangePartitionStay, part.Number, old.Stay, part.Stay)
+		add(ChangePartitionFiring, part.Number, old.Firing, part.Firing)
+		add(ChangePartitionFired, part.Number, old.Fired, part.Fired)
+		add(ChangePartitionExitDelay, part.Number, old.ExitDelay, part.ExitDelay)
+		add(ChangePartitionEntryDelay, part.Number, old.EntryDelay, part.EntryDelay)
+	}
+
+	for i, siren := range s.Sirens {
+		var old Siren
+		if i < len(prev.Sirens) {
+			old = prev.Sirens[i]
+		}
+		add(ChangeSirenTamper, siren.Number, old.Tamper, siren.Tamper)
+		add(ChangeSirenLowBattery, siren.Number, old.LowBattery, siren.LowBattery)
+		add(ChangeSirenSupervisionLoss, siren.Number, old.SupervisionLoss, siren.SupervisionLoss)
+	}
+
+	for i, repeater := range s.Repeaters {
+		var old Repeater
+		if i < len(prev.Repeaters) {
+			old = prev.Repeaters[i]
+		}
+		add(ChangeRepeaterTamper, repeater.Number, old.Tamper, repeater.Tamper)
+		add(ChangeRepeaterLowBattery, repeater.Number, old.LowBattery, repeater.LowBattery)
+		add(ChangeRepeaterSupervisionLoss, repeater.Number, old.SupervisionLoss, repeater.SupervisionLoss)
+	}
+
+	for i, pgm := range s.PGMs {
+		var old PGM
+		if i < len(prev.PGMs) {
+			old = prev.PGMs[i]
+		}
+		add(ChangePGM, pgm.Number, old.On, pgm.On)
+	}
+
+	return changes
+}
@@ -0,0 +1,90 @@
+package amt8000
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusDiff(t *testing.T) {
+	prev := Status{
+		State:   StateDisarmed,
+		Battery: BatteryStatusFull,
+		Zones: []Zone{
+			{Number: 1, Enabled: true, Open: true},
+			{Number: 2, Enabled: true},
+		},
+		Partitions: []Partition{
+			{Number: 0, Enabled: true},
+			{Number: 1, Enabled: true},
+		},
+		Sirens:    []Siren{{Number: 1}},
+		Repeaters: []Repeater{{Number: 1, LowBattery: true}},
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		require.Empty(t, prev.Diff(prev))
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		status := Status{
+			State:   StatePartial,
+			Siren:   true,
+			Battery: BatteryStatusFull,
+			Zones: []Zone{
+				{Number: 1, Enabled: true},
+				{Number: 2, Enabled: true, Violated: true, Tamper: true},
+			},
+			Partitions: []Partition{
+				{Number: 0, Enabled: true},
+				{Number: 1, Enabled: true, Armed: true, Firing: true},
+			},
+			Sirens:    []Siren{{Number: 1, Tamper: true, SupervisionLoss: true}},
+			Repeaters: []Repeater{{Number: 1}},
+			PGMs:      []PGM{{Number: 1, On: true}},
+		}
+		require.Equal(t, []Change{
+			{Kind: ChangeState, StateFrom: StateDisarmed, StateTo: StatePartial},
+			{Kind: ChangeSiren, Set: true},
+			{Kind: ChangeZoneOpen, Number: 1},
+			{Kind: ChangeZoneViolated, Number: 2, Set: true},
+			{Kind: ChangeZoneTamper, Number: 2, Set: true},
+			{Kind: ChangePartitionArmed, Number: 1, Set: true},
+			{Kind: ChangePartitionFiring, Number: 1, Set: true},
+			{Kind: ChangeSirenTamper, Number: 1, Set: true},
+			{Kind: ChangeSirenSupervisionLoss, Number: 1, Set: true},
+			{Kind: ChangeRepeaterLowBattery, Number: 1},
+			{Kind: ChangePGM, Number: 1, Set: true},
+		}, status.Diff(prev))
+	})
+
+	t.Run("from empty", func(t *testing.T) {
+		require.Equal(t, []Change{
+			{Kind: ChangeBattery, BatteryFrom: BatteryStatusUnknown, BatteryTo: BatteryStatusFull},
+			{Kind: ChangeZoneOpen, Number: 1, Set: true},
+			{Kind: ChangeRepeaterLowBattery, Number: 1, Set: true},
+		}, prev.Diff(Status{}))
+	})
+
+	t.Run("nothing set", func(t *testing.T) {
+		status, err := statusFromBytes(make([]byte, statusSize))
+		require.NoError(t, err)
+		require.Empty(t, status.Diff(Status{}))
+	})
+
+	t.Run("string", func(t *testing.T) {
+		require.Equal(t, "zone 3 open: true", Change{Kind: ChangeZoneOpen, Number: 3, Set: true}.String())
+		require.Equal(t, "partition 0 armed: false", Change{Kind: ChangePartitionArmed}.String())
+		require.Equal(t, "system siren: true", Change{Kind: ChangeSiren, Set: true}.String())
+		require.Equal(
+			t,
+			"system state: Disarmed -> Armed",
+			Change{Kind: ChangeState, StateFrom: StateDisarmed, StateTo: StateArmed}.String(),
+		)
+		require.Equal(
+			t,
+			"system battery: full -> low",
+			Change{Kind: ChangeBattery, BatteryFrom: BatteryStatusFull, BatteryTo: BatteryStatusLow}.String(),
+		)
+	})
+}
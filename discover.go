@@ -0,0 +1,187 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Panel is an alarm system found by Discover.
+type Panel struct {
+	IP   string
+	Port string
+
+	// MAC is empty if it could not be resolved, see MacAddress.
+	MAC string
+
+	// Model and Version are only known when the Scanner has the password.
+	Model   string
+	Version string
+}
+
+// Scanner looks for alarm systems in a network.
+// The zero value is ready to use.
+type Scanner struct {
+	// Port to probe, 9009 if empty.
+	Port string
+
+	// Password, if set, is used to read the model and version of the alarm
+	// systems found.
+	// Beware that the alarm systems with another password will see it as a
+	// failed login.
+	Password string
+
+	// Timeout to connect and get a reply from each host, 1s if zero.
+	Timeout time.Duration
+
+	// Concurrency is how many hosts are probed at a time, 64 if zero.
+	Concurrency int
+}
+
+// maxDiscoverHosts is the most hosts Scan probes, i.e. a /16 network.
+const maxDiscoverHosts = 1 << 16
+
+// Discover looks for alarm systems answering ISECNet v2 on port 9009 in the
+// given network, e.g. "192.168.1.0/24".
+// The alarm systems don't seem to answer any broadcast discovery, so every
+// host is probed.
+func Discover(ctx context.Context, cidr string) ([]Panel, error) {
+	return Scanner{}.Scan(ctx, cidr)
+}
+
+// Scan looks for alarm systems in the given network, e.g. "192.168.1.0/24".
+func (s Scanner) Scan(ctx context.Context, cidr string) ([]Panel, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("could not discover: %w", err)
+	}
+	prefix = prefix.Masked()
+	if bits := prefix.Addr().BitLen() - prefix.Bits(); bits > 16 {
+		return nil, fmt.Errorf("could not discover: network %s is too big, the most is %d hosts", prefix, maxDiscoverHosts)
+	}
+	if s.Port == "" {
+		s.Port = "9009"
+	}
+	if s.Timeout == 0 {
+		s.Timeout = time.Second
+	}
+	if s.Concurrency == 0 {
+		s.Concurrency = 64
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		panels []Panel
+		sem    = make(chan struct{}, s.Concurrency)
+	)
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		if !isHost(prefix, addr) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, fmt.Errorf("could not discover: %w", ctx.Err())
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			panel, ok := s.probe(ctx, ip)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			panels = append(panels, panel)
+		}(addr.String())
+	}
+	wg.Wait()
+
+	sort.Slice(panels, func(i, j int) bool {
+		return netip.MustParseAddr(panels[i].IP).Less(netip.MustParseAddr(panels[j].IP))
+	})
+	return panels, ctx.Err()
+}
+
+// isHost tells whether addr is a host address in prefix, i.e. not the IPv4
+// network or broadcast addresses.
+func isHost(prefix netip.Prefix, addr netip.Addr) bool {
+	if !addr.Is4() || prefix.Bits() >= 31 {
+		return true
+	}
+	if addr == prefix.Addr() {
+		return false
+	}
+	b := addr.As4()
+	for i := prefix.Bits(); i < 32; i++ {
+		if b[i/8]&(1<<(7-i%8)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// probe tells whether ip answers ISECNet v2, and if so, gets what it can
+// about it.
+func (s Scanner) probe(ctx context.Context, ip string) (Panel, bool) {
+	if !s.answers(ctx, ip) {
+		return Panel{}, false
+	}
+
+	panel := Panel{IP: ip, Port: s.Port}
+	if mac, err := MacAddress(ip); err == nil {
+		panel.MAC = mac
+	} else {
+		log.Debug("could not get the mac address", "ip", ip, "err", err)
+	}
+	if s.Password == "" {
+		return panel, true
+	}
+
+	cli, err := NewContext(ctx, ip, s.Port, s.Password, s.Timeout)
+	if err != nil {
+		log.Debug("could not connect", "ip", ip, "err", err)
+		return panel, true
+	}
+	defer func() { _ = cli.Close() }()
+	status, err := cli.StatusContext(ctx)
+	if err != nil {
+		log.Debug("could not get the status", "ip", ip, "err", err)
+		return panel, true
+	}
+	panel.Model = status.Model
+	panel.Version = status.Version
+	return panel, true
+}
+
+// answers tells whether ip answers an ISECNet v2 frame with another one, even
+// if refusing it for not being authenticated.
+func (s Scanner) answers(ctx context.Context, ip string) bool {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, s.Port))
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	if err := WriteFrame(conn, newFrame(cmdStatus, nil)); err != nil {
+		return false
+	}
+	if _, err := ReadFrame(conn); err != nil {
+		log.Debug("not an alarm system", "ip", ip, "err", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package amt8000
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	t.Run("panel", func(t *testing.T) {
+		panel := newTestPanel(t)
+		panel.SetModel(0x01, 3, 1, 0)
+
+		panels, err := Scanner{Port: panel.Port()}.Scan(context.Background(), "127.0.0.1/32")
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		require.Equal(t, "127.0.0.1", panels[0].IP)
+		require.Equal(t, panel.Port(), panels[0].Port)
+		require.Empty(t, panels[0].Model)
+		require.Zero(t, panel.Auths())
+
+		panels, err = Scanner{
+			Port:     panel.Port(),
+			Password: panel.Password,
+		}.Scan(context.Background(), "127.0.0.1/32")
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		require.Equal(t, "AMT-8000", panels[0].Model)
+		require.Equal(t, "3.1.0", panels[0].Version)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		panel := newTestPanel(t)
+
+		panels, err := Scanner{
+			Port:     panel.Port(),
+			Password: "654321",
+		}.Scan(context.Background(), "127.0.0.1/32")
+		require.NoError(t, err)
+		require.Len(t, panels, 1)
+		require.Empty(t, panels[0].Model)
+	})
+
+	t.Run("not a panel", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = l.Close() })
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+				_ = conn.Close()
+			}
+		}()
+		_, port, _ := net.SplitHostPort(l.Addr().String())
+
+		panels, err := Scanner{Port: port, Timeout: 100 * time.Millisecond}.Scan(context.Background(), "127.0.0.1/32")
+		require.NoError(t, err)
+		require.Empty(t, panels)
+	})
+
+	t.Run("nothing listening", func(t *testing.T) {
+		panels, err := Scanner{Port: "1", Timeout: 100 * time.Millisecond}.Scan(context.Background(), "127.0.0.0/30")
+		require.NoError(t, err)
+		require.Empty(t, panels)
+	})
+
+	t.Run("invalid network", func(t *testing.T) {
+		_, err := Discover(context.Background(), "nope")
+		require.Error(t, err)
+		_, err = Discover(context.Background(), "10.0.0.0/8")
+		require.ErrorContains(t, err, "too big")
+	})
+
+	t.Run("hosts", func(t *testing.T) {
+		prefix := netip.MustParsePrefix("192.168.1.0/24")
+		require.False(t, isHost(prefix, netip.MustParseAddr("192.168.1.0")))
+		require.True(t, isHost(prefix, netip.MustParseAddr("192.168.1.1")))
+		require.True(t, isHost(prefix, netip.MustParseAddr("192.168.1.254")))
+		require.False(t, isHost(prefix, netip.MustParseAddr("192.168.1.255")))
+		require.True(t, isHost(netip.MustParsePrefix("192.168.1.1/32"), netip.MustParseAddr("192.168.1.1")))
+	})
+}
@@ -0,0 +1,41 @@
+package amt8000
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caarlos0/homekit-amt8000/contactid"
+)
+
+// Event is a notification the alarm system sends on its own, e.g. when a
+// zone is violated, someone arms or disarms it, or the AC power is lost.
+type Event struct {
+	// When the event was received.
+	Time time.Time
+
+	contactid.Message
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.Message)
+}
+
+// parseEvent parses the data of an event frame, which is a Contact ID message
+// encoded as in contactid.Decode.
+func parseEvent(data []byte) (Event, error) {
+	msg, err := contactid.Decode(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid event: %w", err)
+	}
+
+	switch msg.Qualifier {
+	case contactid.QualifierNew, contactid.QualifierRestore, contactid.QualifierStatus:
+	default:
+		return Event{}, fmt.Errorf("invalid event qualifier: %d", msg.Qualifier)
+	}
+
+	return Event{
+		Time:    time.Now(),
+		Message: msg,
+	}, nil
+}
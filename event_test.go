@@ -0,0 +1,57 @@
+package amt8000
+
+import (
+	"testing"
+
+	"github.com/caarlos0/homekit-amt8000/contactid"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvent(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		event, err := parseEvent([]byte{
+			0x01, 0x02, 0x03, 0x04, // account
+			0x03,             // restore
+			0x01, 0x03, 0x0a, // burglary
+			0x0a, 0x02, // partition
+			0x0a, 0x01, 0x05, // zone
+		})
+		require.NoError(t, err)
+		require.Equal(t, contactid.Message{
+			Account:   1234,
+			Qualifier: contactid.QualifierRestore,
+			Code:      contactid.CodeBurglary,
+			Partition: 2,
+			Zone:      15,
+		}, event.Message)
+		require.Equal(t, "Burglary restore: 1234 18 3130 02 015", event.String())
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := parseEvent([]byte{0x01})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid digit", func(t *testing.T) {
+		_, err := parseEvent([]byte{
+			0x01, 0x02, 0x03, 0x0f,
+			0x01,
+			0x01, 0x03, 0x0a,
+			0x0a, 0x02,
+			0x0a, 0x01, 0x05,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid qualifier", func(t *testing.T) {
+		_, err := parseEvent([]byte{
+			0x01, 0x02, 0x03, 0x04,
+			0x02,
+			0x01, 0x03, 0x0a,
+			0x0a, 0x02,
+			0x0a, 0x01, 0x05,
+		})
+		require.Error(t, err)
+	})
+}
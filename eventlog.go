@@ -0,0 +1,101 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/caarlos0/homekit-amt8000/contactid"
+)
+
+// LogEntry is an entry of the event log kept by the alarm system.
+type LogEntry struct {
+	Time time.Time
+
+	Code contactid.Code
+
+	// Whether this is a restore of a previous event, e.g. arming (close)
+	// instead of disarming (open).
+	Restore bool
+
+	// Partition, zone and user are 0 when they don't apply to the event.
+	Partition int
+	Zone      int
+	User      int
+}
+
+// Description returns a human readable description of the entry, e.g.
+// "Burglary restore".
+func (e LogEntry) Description() string {
+	qualifier := contactid.QualifierNew
+	if e.Restore {
+		qualifier = contactid.QualifierRestore
+	}
+	return contactid.Message{Qualifier: qualifier, Code: e.Code}.Description()
+}
+
+const logEntrySize = 13
+
+// Events reads count entries of the event log, starting at from.
+// Entry 0 is the most recent one.
+func (c *Client) Events(from, count int) ([]LogEntry, error) {
+	return c.EventsContext(context.Background(), from, count)
+}
+
+func (c *Client) EventsContext(ctx context.Context, from, count int) ([]LogEntry, error) {
+	log.Debug("events", "from", from, "count", count)
+	if from < 0 || from > 0xffff {
+		return nil, fmt.Errorf("invalid event log index: %d", from)
+	}
+	if count < 1 || count > 0xff {
+		return nil, fmt.Errorf("invalid event log count: %d", count)
+	}
+
+	data := append(splitIntoOctets(from), byte(count))
+	reply, err := c.request(ctx, cmdEventLog, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read events: %w", err)
+	}
+	if err := checkReply(cmdEventLog, reply); err != nil {
+		return nil, fmt.Errorf("could not read events: %w", err)
+	}
+	return logEntriesFromBytes(reply.Data, c.loc)
+}
+
+// logEntriesFromBytes decodes the event log entries, each of them being:
+//
+//	qualifier (1) | code (3) | partition | zone | user | day | month | year | hour | minute | second
+//
+// The qualifier and code are Contact ID digits, one per byte, the year is
+// relative to 2000, and the time is in the alarm system timezone, loc.
+func logEntriesFromBytes(resp []byte, loc *time.Location) ([]LogEntry, error) {
+	if len(resp)%logEntrySize != 0 {
+		return nil, fmt.Errorf("invalid event log size: %d", len(resp))
+	}
+
+	var entries []LogEntry
+	for i := 0; i < len(resp); i += logEntrySize {
+		b := resp[i : i+logEntrySize]
+		qualifier, err := contactid.DecodeDigits(b[0:1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid event log entry %d: %w", i/logEntrySize, err)
+		}
+		code, err := contactid.DecodeDigits(b[1:4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid event log entry %d: %w", i/logEntrySize, err)
+		}
+		entries = append(entries, LogEntry{
+			Time: time.Date(
+				2000+int(b[9]), time.Month(b[8]), int(b[7]),
+				int(b[10]), int(b[11]), int(b[12]), 0,
+				loc,
+			),
+			Code:      contactid.Code(code),
+			Restore:   contactid.Qualifier(qualifier) == contactid.QualifierRestore,
+			Partition: int(b[4]),
+			Zone:      int(b[5]),
+			User:      int(b[6]),
+		})
+	}
+	return entries, nil
+}
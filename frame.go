@@ -0,0 +1,195 @@
+package amt8000
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrShortFrame  = errors.New("short frame")
+	ErrBadChecksum = errors.New("bad checksum")
+)
+
+// UnexpectedCommandError happens when the alarm system replies to a command
+// with a different one.
+type UnexpectedCommandError struct {
+	Want int
+	Got  int
+}
+
+func (e UnexpectedCommandError) Error() string {
+	return fmt.Sprintf("unexpected command: wanted %#04x, got %#04x", e.Want, e.Got)
+}
+
+// reasons the alarm system gives when refusing a command.
+const (
+	reasonInvalidPacket      = 0x00
+	reasonWrongPassword      = 0x01
+	reasonInvalidCommand     = 0x02
+	reasonNotPartitioned     = 0x03
+	reasonOpenZones          = 0x04
+	reasonDiscontinued       = 0x05
+	reasonNoBypassPermission = 0x06
+	reasonNoDisarmPermission = 0x07
+	reasonBypassArmed        = 0x08
+)
+
+// RefusedError happens when the alarm system refuses a command.
+// It wraps ErrOpenZones, ErrNotPermitted, ErrInvalidZone or
+// ErrInvalidPartition when the reason is one of those.
+type RefusedError struct {
+	Cmd    int
+	Reason byte
+}
+
+func (e RefusedError) Error() string {
+	return fmt.Sprintf("command %#04x refused: %s", e.Cmd, e.reason())
+}
+
+func (e RefusedError) Unwrap() error {
+	switch e.Reason {
+	case reasonOpenZones:
+		return ErrOpenZones
+	case reasonNotPartitioned:
+		return ErrInvalidPartition
+	case reasonNoBypassPermission, reasonNoDisarmPermission, reasonBypassArmed:
+		return ErrNotPermitted
+	case reasonInvalidPacket:
+		// the zone, partition, pgm or user are the only things that might be
+		// wrong in the packets we send.
+		switch e.Cmd {
+		case cmdBypass:
+			return ErrInvalidZone
+		case cmdArm, cmdTurnOffSiren:
+			return ErrInvalidPartition
+		case cmdPGM:
+			return ErrInvalidPGM
+		case cmdUser:
+			return ErrInvalidUser
+		}
+	}
+	return nil
+}
+
+func (e RefusedError) reason() string {
+	switch e.Reason {
+	case reasonInvalidPacket:
+		return "invalid packet"
+	case reasonWrongPassword:
+		return "wrong password"
+	case reasonInvalidCommand:
+		return "invalid command"
+	case reasonNotPartitioned:
+		return "not partitioned"
+	case reasonOpenZones:
+		return "open zones"
+	case reasonDiscontinued:
+		return "discontinued command"
+	case reasonNoBypassPermission:
+		return "no bypass permission"
+	case reasonNoDisarmPermission:
+		return "no disarm permission"
+	case reasonBypassArmed:
+		return "bypass not allowed while armed"
+	default:
+		return fmt.Sprintf("unknown reason %#02x", e.Reason)
+	}
+}
+
+// Frame is a single ISECNet v2 packet.
+//
+// On the wire it looks like this:
+//
+//	dst (2) | src (2) | length (2) | cmd (2) | data (length-2) | checksum (1)
+type Frame struct {
+	Dst  int
+	Src  int
+	Cmd  int
+	Data []byte
+}
+
+func newFrame(cmd int, data []byte) Frame {
+	return Frame{
+		Dst:  dstID,
+		Src:  srcID,
+		Cmd:  cmd,
+		Data: data,
+	}
+}
+
+// Bytes encodes the frame, including its length and checksum.
+func (f Frame) Bytes() []byte {
+	payload := []byte{}
+	payload = append(payload, splitIntoOctets(f.Dst)...)
+	payload = append(payload, splitIntoOctets(f.Src)...)
+	payload = append(payload, splitIntoOctets(len(f.Data)+2)...)
+	payload = append(payload, splitIntoOctets(f.Cmd)...)
+	payload = append(payload, f.Data...)
+	payload = append(payload, checksum(payload))
+	return payload
+}
+
+// isError tells whether the frame is the alarm system refusing a command.
+func (f Frame) isError() bool {
+	return f.Cmd == cmdNack
+}
+
+// checkReply returns a RefusedError if the reply is the alarm system refusing
+// cmd.
+func checkReply(cmd int, reply Frame) error {
+	if !reply.isError() {
+		return nil
+	}
+	err := RefusedError{Cmd: cmd, Reason: reasonInvalidCommand}
+	if len(reply.Data) > 0 {
+		err.Reason = reply.Data[0]
+	}
+	return err
+}
+
+// WriteFrame writes the encoded frame to w.
+func WriteFrame(w io.Writer, f Frame) error {
+	_, err := w.Write(f.Bytes())
+	return err
+}
+
+// ReadFrame reads a single frame from r, using the length in its header to
+// know how much to read, and validating its checksum.
+func ReadFrame(r io.Reader) (Frame, error) {
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Frame{}, shortFrame(err)
+	}
+
+	length := mergeOctets(header[4:6])
+	if length < 2 {
+		return Frame{}, fmt.Errorf("%w: invalid length %d", ErrShortFrame, length)
+	}
+
+	// the length covers the command and data, the checksum comes after it.
+	body := make([]byte, length+1)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Frame{}, shortFrame(err)
+	}
+
+	buf := append(header, body...)
+	want := checksum(buf[:len(buf)-1])
+	if got := buf[len(buf)-1]; got != want {
+		return Frame{}, fmt.Errorf("%w: wanted %#02x, got %#02x", ErrBadChecksum, want, got)
+	}
+
+	return Frame{
+		Dst:  mergeOctets(buf[0:2]),
+		Src:  mergeOctets(buf[2:4]),
+		Cmd:  mergeOctets(buf[6:8]),
+		Data: buf[8 : len(buf)-1],
+	}, nil
+}
+
+func shortFrame(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrShortFrame, err)
+	}
+	return err
+}
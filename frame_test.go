@@ -0,0 +1,98 @@
+package amt8000
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame(t *testing.T) {
+	frame := newFrame(cmdArm, []byte{0x01, subCmdArm})
+
+	t.Run("bytes", func(t *testing.T) {
+		require.Equal(t, []byte{
+			0x00, 0x00, // dst
+			0x8f, 0xe0, // src
+			0x00, 0x04, // length
+			0x40, 0x1e, // cmd
+			0x01, 0x01, // data
+			0xca, // checksum
+		}, frame.Bytes())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteFrame(&buf, frame))
+		require.NoError(t, WriteFrame(&buf, newFrame(cmdStatus, nil)))
+
+		got, err := ReadFrame(&buf)
+		require.NoError(t, err)
+		require.Equal(t, frame, got)
+
+		got, err = ReadFrame(&buf)
+		require.NoError(t, err)
+		require.Equal(t, cmdStatus, got.Cmd)
+		require.Empty(t, got.Data)
+
+		_, err = ReadFrame(&buf)
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("short", func(t *testing.T) {
+		b := frame.Bytes()
+		_, err := ReadFrame(bytes.NewReader(b[:len(b)-2]))
+		require.ErrorIs(t, err, ErrShortFrame)
+
+		_, err = ReadFrame(bytes.NewReader(b[:3]))
+		require.ErrorIs(t, err, ErrShortFrame)
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := ReadFrame(bytes.NewReader([]byte{0, 0, 0, 0, 0, 1, 0xff}))
+		require.ErrorIs(t, err, ErrShortFrame)
+	})
+
+	t.Run("bad checksum", func(t *testing.T) {
+		b := frame.Bytes()
+		b[len(b)-1] ^= 0xff
+		_, err := ReadFrame(bytes.NewReader(b))
+		require.ErrorIs(t, err, ErrBadChecksum)
+	})
+
+	t.Run("is error", func(t *testing.T) {
+		require.False(t, frame.isError())
+		require.False(t, newFrame(cmdAuth, nil).isError())
+		require.True(t, newFrame(cmdNack, nil).isError())
+		require.False(t, newFrame(0xf0fe, nil).isError())
+	})
+
+	t.Run("check reply", func(t *testing.T) {
+		require.NoError(t, checkReply(cmdArm, newFrame(cmdArm, nil)))
+
+		err := checkReply(cmdArm, newFrame(cmdNack, []byte{reasonOpenZones}))
+		require.ErrorIs(t, err, ErrOpenZones)
+		require.EqualError(t, err, "command 0x401e refused: open zones")
+
+		require.ErrorIs(t, checkReply(cmdArm, newFrame(cmdNack, []byte{reasonNoDisarmPermission})), ErrNotPermitted)
+		require.ErrorIs(t, checkReply(cmdBypass, newFrame(cmdNack, []byte{reasonInvalidPacket})), ErrInvalidZone)
+		require.ErrorIs(t, checkReply(cmdArm, newFrame(cmdNack, []byte{reasonNotPartitioned})), ErrInvalidPartition)
+
+		err = checkReply(cmdPanic, newFrame(cmdNack, nil))
+		require.Equal(t, RefusedError{Cmd: cmdPanic, Reason: reasonInvalidCommand}, err)
+		require.Nil(t, errors.Unwrap(err))
+	})
+}
+
+func TestParseAuthResponse(t *testing.T) {
+	require.NoError(t, parseAuthResponse(newFrame(cmdAuth, []byte{0x00})))
+	require.ErrorIs(t, parseAuthResponse(newFrame(cmdAuth, []byte{0x01})), ErrInvalidPassword)
+	require.Error(t, parseAuthResponse(newFrame(cmdAuth, nil)))
+
+	var uerr UnexpectedCommandError
+	err := parseAuthResponse(newFrame(cmdStatus, []byte{0x00}))
+	require.True(t, errors.As(err, &uerr))
+	require.Equal(t, UnexpectedCommandError{Want: cmdAuth, Got: cmdStatus}, uerr)
+}
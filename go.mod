@@ -5,7 +5,6 @@ go 1.25.0
 require (
 	github.com/brutella/hap v0.0.35
 	github.com/caarlos0/env/v11 v11.3.1
-	github.com/caarlos0/sync v0.0.2
 	github.com/cenkalti/backoff/v4 v4.3.0
 	github.com/charmbracelet/log v0.4.2
 	github.com/j-keck/arping v1.0.3
@@ -28,6 +27,7 @@ require (
 	github.com/go-chi/chi v1.5.4 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
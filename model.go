@@ -6,18 +6,54 @@ import (
 )
 
 type Status struct {
-	Model       string
-	Version     string
-	State       State
-	ZonesFiring bool
-	ZonesClosed bool
-	Siren       bool
-	Tamper      bool
-	Battery     BatteryStatus
-	Partitions  []Partition
-	Zones       []Zone
-	Sirens      []Siren
-	Repeaters   []Repeater
+	Model        string
+	Capabilities Capabilities
+	Version      string
+	State        State
+	ZonesFiring  bool
+	ZonesClosed  bool
+	Siren        bool
+	Tamper       bool
+	Battery      BatteryStatus
+	Troubles     Troubles
+	Partitions   []Partition
+	Zones        []Zone
+	Sirens       []Siren
+	Repeaters    []Repeater
+	PGMs         []PGM
+}
+
+// Troubles are the problems the alarm system reports, other than tampers and
+// batteries, which are reported on their own.
+type Troubles struct {
+	ACLoss      bool
+	Ethernet    bool
+	GPRS        bool
+	PhoneLine   bool
+	ClockNotSet bool
+	RFJamming   bool
+
+	// Wireless devices that stopped reporting to the alarm system.
+	Supervision Supervision
+}
+
+// Supervision lists, by number, the wireless devices that lost supervision.
+type Supervision struct {
+	Zones     []int
+	Sirens    []int
+	Repeaters []int
+}
+
+// Any tells whether there is any trouble.
+func (t Troubles) Any() bool {
+	return t.ACLoss || t.Communication() || t.ClockNotSet || t.RFJamming ||
+		len(t.Supervision.Zones)+len(t.Supervision.Sirens)+len(t.Supervision.Repeaters) > 0
+}
+
+// Communication tells whether any of the ways the alarm system reports events
+// is failing.
+func (t Troubles) Communication() bool {
+	return t.Ethernet || t.GPRS || t.PhoneLine
 }
 
 type Zone struct {
@@ -28,6 +64,18 @@ type Zone struct {
 	Anulated   bool
 	Tamper     bool
 	LowBattery bool
+
+	// Whether the zone is triggering the alarm right now, and whether it did
+	// since the firings were last cleaned (alarm memory).
+	Firing bool
+	Fired  bool
+
+	// Whether the alarm system stopped hearing from the wireless zone.
+	// Always false for wired and disabled zones.
+	SupervisionLoss bool
+
+	// RF signal level of the wireless zone, from 1 to 10, or 0 if unknown.
+	SignalLevel int
 }
 
 // Shows the sensor as open if it either is open or if it is violated.
@@ -36,15 +84,19 @@ func (z Zone) IsOpen() bool {
 }
 
 type Siren struct {
-	Number     int
-	Tamper     bool
-	LowBattery bool
+	Number          int
+	Tamper          bool
+	LowBattery      bool
+	SupervisionLoss bool
+	SignalLevel     int
 }
 
 type Repeater struct {
-	Number     int
-	Tamper     bool
-	LowBattery bool
+	Number          int
+	Tamper          bool
+	LowBattery      bool
+	SupervisionLoss bool
+	SignalLevel     int
 }
 
 type Partition struct {
@@ -54,23 +106,85 @@ type Partition struct {
 	Fired   bool
 	Firing  bool
 	Stay    bool
+
+	// ExitDelay is set while the partition is being armed: it is only Armed
+	// once the exit delay is over.
+	ExitDelay bool
+
+	// EntryDelay is set while an entry zone of the armed partition was
+	// violated, and it'll fire unless disarmed before the delay is over.
+	EntryDelay bool
 }
 
+// ExitDelay tells whether any partition exit delay is running.
+func (s Status) ExitDelay() bool {
+	for _, part := range s.Partitions {
+		if part.Enabled && part.ExitDelay {
+			return true
+		}
+	}
+	return false
+}
+
+// EntryDelay tells whether any partition entry delay is running.
+func (s Status) EntryDelay() bool {
+	for _, part := range s.Partitions {
+		if part.Enabled && part.EntryDelay {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// statusSize is the size of the status of the AMT-8000, the layout all
+	// models share.
+	statusSize = 143
+
+	// minStatusSize is the least we need to make sense of a status: model,
+	// version, state, partitions, zones, tamper and troubles.
+	minStatusSize = 113
+
+	// what comes after the zones, only decoded if the status has it.
+	pgmsStatusSize    = 115
+	devicesStatusSize = 119 // sirens and repeaters
+	batteryStatusSize = 135
+)
+
+// statusFromBytes decodes the status.
+// Models and firmwares send statuses of different sizes, so anything after
+// the known layout is ignored.
+// Missing PGMs, sirens and repeaters are left out of both the status and its
+// capabilities, and a missing battery status is unknown, as they would
+// otherwise look fine.
 func statusFromBytes(resp []byte) (Status, error) {
-	if len(resp) != 143 {
+	if len(resp) < minStatusSize {
 		return Status{}, fmt.Errorf("invalid status:\n%s", hex.Dump(resp))
 	}
+	if len(resp) < statusSize {
+		log.Debug("short status", "size", len(resp))
+	}
+
+	model := modelFor(resp[0])
+	if len(resp) < pgmsStatusSize {
+		model.capabilities.PGMs = 0
+	}
+	if len(resp) < devicesStatusSize {
+		model.capabilities.Sirens = 0
+		model.capabilities.Repeaters = 0
+	}
 	status := Status{
-		Model:       modelName(resp[0]),
-		Version:     version(resp[1:4]),
-		State:       State(resp[20] >> 5 & 0x03),
-		ZonesFiring: resp[20]&0x8 > 0,
-		ZonesClosed: resp[20]&0x4 > 0,
-		Siren:       resp[20]&0x2 > 0,
-		Zones:       make([]Zone, 64),
-		Sirens:      make([]Siren, 2),
-		Repeaters:   make([]Repeater, 2),
-		Partitions:  make([]Partition, 16),
+		Model:        model.name,
+		Capabilities: model.capabilities,
+		Version:      version(resp[1:4]),
+		State:        State(resp[20] >> 5 & 0x03),
+		ZonesFiring:  resp[20]&0x8 > 0,
+		ZonesClosed:  resp[20]&0x4 > 0,
+		Siren:        resp[20]&0x2 > 0,
+		Zones:        make([]Zone, 64),
+		Sirens:       make([]Siren, 2),
+		Repeaters:    make([]Repeater, 2),
+		Partitions:   make([]Partition, 16),
 	}
 
 	// partitions
@@ -83,10 +197,13 @@ func statusFromBytes(resp []byte) (Status, error) {
 			Firing:  octet&0x04 > 0,
 			Fired:   octet&0x08 > 0,
 			Stay:    octet&0x40 > 0,
+
+			ExitDelay:  octet&0x10 > 0,
+			EntryDelay: octet&0x20 > 0,
 		}
 	}
 
-	for i := 0; i < 48; i++ {
+	for i := range status.Zones {
 		status.Zones[i].Number = i + 1
 	}
 
@@ -95,41 +212,37 @@ func statusFromBytes(resp []byte) (Status, error) {
 		status.Repeaters[i].Number = i + 1
 	}
 
-	for i, octet := range resp[12:19] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].Enabled = octet&(1<<j) > 0
-		}
-	}
-	for i, octet := range resp[38:45] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].Open = octet&(1<<j) > 0
-		}
-	}
+	zoneBits(status.Zones, resp[12:20], func(z *Zone, set bool) { z.Enabled = set })
+	zoneBits(status.Zones, resp[38:46], func(z *Zone, set bool) { z.Open = set })
+	zoneBits(status.Zones, resp[46:54], func(z *Zone, set bool) { z.Violated = set })
+	zoneBits(status.Zones, resp[54:62], func(z *Zone, set bool) { z.Anulated = set })
+	zoneBits(status.Zones, resp[62:70], func(z *Zone, set bool) { z.Firing = set })
+	zoneBits(status.Zones, resp[72:80], func(z *Zone, set bool) { z.Fired = set })
+	zoneBits(status.Zones, resp[89:97], func(z *Zone, set bool) { z.Tamper = set })
+	zoneBits(status.Zones, resp[105:113], func(z *Zone, set bool) { z.LowBattery = set })
+	zoneBits(status.Zones, resp[80:88], func(z *Zone, set bool) { z.SupervisionLoss = set })
 
-	for i, octet := range resp[46:53] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].Violated = octet&(1<<j) > 0
-		}
+	if len(resp) >= devicesStatusSize {
+		sirensAndRepeatersFromBytes(resp, &status)
 	}
-
-	for i, octet := range resp[54:62] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].Anulated = octet&(1<<j) > 0
-		}
+	if len(resp) >= pgmsStatusSize {
+		status.PGMs = pgmsFromBytes(resp[113:115])
 	}
 
-	for i, octet := range resp[89:96] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].Tamper = octet&(1<<j) > 0
-		}
-	}
+	status.Battery = batteryStatusFor(resp)
+	status.Tamper = resp[71]&(1<<0x01) > 0
+	status.Troubles = troublesFor(resp)
 
-	for i, octet := range resp[105:112] {
-		for j := 0; j < 8; j++ {
-			status.Zones[j+i*8].LowBattery = octet&(1<<j) > 0
-		}
-	}
+	// only what the model actually has.
+	status.Zones = status.Zones[:model.capabilities.Zones]
+	status.Partitions = status.Partitions[:model.capabilities.Partitions]
+	status.Sirens = status.Sirens[:model.capabilities.Sirens]
+	status.Repeaters = status.Repeaters[:model.capabilities.Repeaters]
+	status.PGMs = status.PGMs[:model.capabilities.PGMs]
+	return status, nil
+}
 
+func sirensAndRepeatersFromBytes(resp []byte, status *Status) {
 	// sirens
 	for i, octet := range resp[99:101] {
 		status.Sirens[i].Tamper = octet&0x01 > 0
@@ -137,18 +250,64 @@ func statusFromBytes(resp []byte) (Status, error) {
 	for i, octet := range resp[115:117] {
 		status.Sirens[i].LowBattery = octet&0x01 > 0
 	}
+	for i, octet := range resp[97:99] {
+		status.Sirens[i].SupervisionLoss = octet&0x01 > 0
+	}
 
 	// repeaters
 	for i, octet := range resp[101:103] {
-		status.Repeaters[i].Number = i + 1
 		status.Repeaters[i].Tamper = octet&0x01 > 0
 	}
 	for i, octet := range resp[117:119] {
-		status.Repeaters[i].Number = i + 1
 		status.Repeaters[i].LowBattery = octet&0x01 > 0
 	}
+	for i, octet := range resp[103:105] {
+		status.Repeaters[i].SupervisionLoss = octet&0x01 > 0
+	}
+}
 
-	status.Battery = batteryStatusFor(resp)
-	status.Tamper = resp[71]&(1<<0x01) > 0
-	return status, nil
+func troublesFor(resp []byte) Troubles {
+	communication := resp[70]
+	generalTroubles := resp[71]
+	troubles := Troubles{
+		ACLoss:      generalTroubles&(1<<0x00) > 0,
+		ClockNotSet: generalTroubles&(1<<0x04) > 0,
+		RFJamming:   generalTroubles&(1<<0x05) > 0,
+		Ethernet:    communication&(1<<0x00) > 0,
+		GPRS:        communication&(1<<0x01) > 0,
+		PhoneLine:   communication&(1<<0x02) > 0,
+	}
+
+	for i, octet := range resp[80:88] {
+		for j := 0; j < 8; j++ {
+			if octet&(1<<j) > 0 {
+				troubles.Supervision.Zones = append(troubles.Supervision.Zones, j+i*8+1)
+			}
+		}
+	}
+	// sirens and repeaters are left out of shorter statuses.
+	if len(resp) < devicesStatusSize {
+		return troubles
+	}
+	for i, octet := range resp[97:99] {
+		if octet&0x01 > 0 {
+			troubles.Supervision.Sirens = append(troubles.Supervision.Sirens, i+1)
+		}
+	}
+	for i, octet := range resp[103:105] {
+		if octet&0x01 > 0 {
+			troubles.Supervision.Repeaters = append(troubles.Supervision.Repeaters, i+1)
+		}
+	}
+	return troubles
+}
+
+// zoneBits calls set for each zone with its bit in octets, one bit per zone,
+// least significant bit first.
+func zoneBits(zones []Zone, octets []byte, set func(z *Zone, set bool)) {
+	for i, octet := range octets {
+		for j := 0; j < 8; j++ {
+			set(&zones[j+i*8], octet&(1<<j) > 0)
+		}
+	}
 }
@@ -0,0 +1,46 @@
+package amt8000
+
+import "fmt"
+
+// Capabilities tell how many devices of each kind an alarm system model
+// supports.
+type Capabilities struct {
+	Zones      int
+	Partitions int
+	Sirens     int
+	Repeaters  int
+	PGMs       int
+}
+
+type model struct {
+	name         string
+	capabilities Capabilities
+}
+
+// models by the code they report in the status.
+// Only the AMT-8000 code is known: other models of the ISECNet v2 family, e.g.
+// the AMT-8000 Pro, are reported as unknown, and their status is decoded by
+// its size alone, leaving out whatever a shorter status does not have.
+var models = map[byte]model{
+	0x01: {"AMT-8000", Capabilities{Zones: 64, Partitions: 16, Sirens: 2, Repeaters: 2, PGMs: 16}},
+}
+
+// unknownCapabilities are assumed for unknown models: everything the status
+// layout has room for, further limited by the status size.
+var unknownCapabilities = Capabilities{
+	Zones:      maxZones,
+	Partitions: maxPartitions,
+	Sirens:     2,
+	Repeaters:  2,
+	PGMs:       maxPGMs,
+}
+
+func modelFor(code byte) model {
+	if m, ok := models[code]; ok {
+		return m
+	}
+	return model{
+		name:         fmt.Sprintf("Unknown (%#02x)", code),
+		capabilities: unknownCapabilities,
+	}
+}
@@ -0,0 +1,105 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Names are the names programmed in the alarm system.
+// Zones and users are indexed by number-1, partitions by their number, as in
+// Status.Partitions, and they might be empty if not programmed.
+type Names struct {
+	Zones      []string
+	Partitions []string
+	Users      []string
+}
+
+// Zone returns the name of the given zone, from 1 to 64, or an empty string
+// if there is none.
+func (n Names) Zone(number int) string {
+	return nameAt(n.Zones, number)
+}
+
+// Partition returns the name of the given partition, from 0 to 15, as in
+// Status.Partitions, or an empty string if there is none.
+func (n Names) Partition(number int) string {
+	return nameAt(n.Partitions, number+1)
+}
+
+// User returns the name of the given user, or an empty string if there is
+// none.
+func (n Names) User(number int) string {
+	return nameAt(n.Users, number)
+}
+
+func nameAt(names []string, number int) string {
+	if number < 1 || number > len(names) {
+		return ""
+	}
+	return names[number-1]
+}
+
+const (
+	namesZones      = 0x01
+	namesPartitions = 0x02
+	namesUsers      = 0x03
+)
+
+const (
+	maxZones      = 64
+	maxPartitions = 16
+	maxUsers      = 98
+	nameSize      = 16
+)
+
+// Names downloads the zone, partition and user names programmed in the alarm
+// system.
+func (c *Client) Names() (Names, error) {
+	return c.NamesContext(context.Background())
+}
+
+func (c *Client) NamesContext(ctx context.Context) (Names, error) {
+	log.Debug("names")
+	var names Names
+	for _, kind := range []struct {
+		kind  byte
+		count int
+		names *[]string
+	}{
+		{namesZones, maxZones, &names.Zones},
+		{namesPartitions, maxPartitions, &names.Partitions},
+		{namesUsers, maxUsers, &names.Users},
+	} {
+		result, err := c.names(ctx, kind.kind, kind.count)
+		if err != nil {
+			return Names{}, err
+		}
+		*kind.names = result
+	}
+	return names, nil
+}
+
+func (c *Client) names(ctx context.Context, kind byte, count int) ([]string, error) {
+	reply, err := c.request(ctx, cmdNames, []byte{kind, 0x00, byte(count)})
+	if err != nil {
+		return nil, fmt.Errorf("could not read names: %w", err)
+	}
+	if err := checkReply(cmdNames, reply); err != nil {
+		return nil, fmt.Errorf("could not read names: %w", err)
+	}
+	return namesFromBytes(reply.Data)
+}
+
+// namesFromBytes decodes fixed size names, padded with either spaces or
+// zeroes.
+func namesFromBytes(resp []byte) ([]string, error) {
+	if len(resp)%nameSize != 0 {
+		return nil, fmt.Errorf("invalid names size: %d", len(resp))
+	}
+	var names []string
+	for i := 0; i < len(resp); i += nameSize {
+		names = append(names, strings.TrimRight(string(resp[i:i+nameSize]), " \x00"))
+	}
+	return names, nil
+}
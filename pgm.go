@@ -0,0 +1,72 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const maxPGMs = 16
+
+// PGM is a programmable output, e.g. wired to a gate motor or a light.
+type PGM struct {
+	Number int
+	On     bool
+}
+
+// SetPGM turns the given PGM, from 1 to 16, on or off.
+func (c *Client) SetPGM(pgm int, on bool) error {
+	return c.SetPGMContext(context.Background(), pgm, on)
+}
+
+func (c *Client) SetPGMContext(ctx context.Context, pgm int, on bool) error {
+	log.Debug("set pgm", "pgm", pgm, "on", on)
+	if pgm < 1 || pgm > maxPGMs {
+		return fmt.Errorf("could not set pgm %v=%v: %w", pgm, on, ErrInvalidPGM)
+	}
+
+	var b byte = 0x00
+	if on {
+		b = 0x01
+	}
+
+	reply, err := c.request(ctx, cmdPGM, []byte{byte(pgm - 1), b})
+	if err != nil {
+		return fmt.Errorf("could not set pgm %v=%v: %w", pgm, on, err)
+	}
+	if err := checkReply(cmdPGM, reply); err != nil {
+		return fmt.Errorf("could not set pgm %v=%v: %w", pgm, on, err)
+	}
+	return nil
+}
+
+// PulsePGM turns the given PGM on, and then off again after d, e.g. to open a
+// gate.
+// The PGM is turned off even if ctx is canceled while waiting.
+func (c *Client) PulsePGM(pgm int, d time.Duration) error {
+	return c.PulsePGMContext(context.Background(), pgm, d)
+}
+
+func (c *Client) PulsePGMContext(ctx context.Context, pgm int, d time.Duration) error {
+	if err := c.SetPGMContext(ctx, pgm, true); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+	return c.SetPGMContext(context.WithoutCancel(ctx), pgm, false)
+}
+
+// pgmsFromBytes decodes one bit per PGM, PGM 1 being the lowest bit of the
+// first octet.
+func pgmsFromBytes(resp []byte) []PGM {
+	pgms := make([]PGM, maxPGMs)
+	for i := range pgms {
+		pgms[i] = PGM{
+			Number: i + 1,
+			On:     resp[i/8]&(1<<(i%8)) > 0,
+		}
+	}
+	return pgms
+}
@@ -0,0 +1,164 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ZoneFunction is how the alarm system reacts to a violated zone.
+type ZoneFunction byte
+
+const (
+	ZoneFunctionDisabled ZoneFunction = 0x00
+	ZoneFunctionDelayed  ZoneFunction = 0x01 // fires after the entry delay
+	ZoneFunctionInstant  ZoneFunction = 0x02 // fires right away
+	ZoneFunctionFollower ZoneFunction = 0x03 // delayed only if an entry delay is running
+	ZoneFunction24h      ZoneFunction = 0x04 // fires even when disarmed
+	ZoneFunctionPanic    ZoneFunction = 0x05
+	ZoneFunctionFire     ZoneFunction = 0x06
+)
+
+func (f ZoneFunction) String() string {
+	switch f {
+	case ZoneFunctionDisabled:
+		return "disabled"
+	case ZoneFunctionDelayed:
+		return "delayed"
+	case ZoneFunctionInstant:
+		return "instant"
+	case ZoneFunctionFollower:
+		return "follower"
+	case ZoneFunction24h:
+		return "24h"
+	case ZoneFunctionPanic:
+		return "panic"
+	case ZoneFunctionFire:
+		return "fire"
+	default:
+		return fmt.Sprintf("unknown (%#02x)", byte(f))
+	}
+}
+
+// SensorType is the kind of sensor paired to a zone.
+type SensorType byte
+
+const (
+	SensorUnknown SensorType = 0x00
+	SensorMotion  SensorType = 0x01
+	SensorContact SensorType = 0x02 // doors and windows
+	SensorSmoke   SensorType = 0x03
+	SensorButton  SensorType = 0x04 // panic buttons and remote controls
+)
+
+func (s SensorType) String() string {
+	switch s {
+	case SensorUnknown:
+		return "unknown"
+	case SensorMotion:
+		return "motion"
+	case SensorContact:
+		return "contact"
+	case SensorSmoke:
+		return "smoke"
+	case SensorButton:
+		return "button"
+	default:
+		return fmt.Sprintf("unknown (%#02x)", byte(s))
+	}
+}
+
+// ZoneProgramming is how a zone is programmed in the alarm system.
+type ZoneProgramming struct {
+	Number int
+
+	// Partition the zone belongs to.
+	// Zones in partition 0 belong to all of them.
+	Partition int
+
+	Function ZoneFunction
+	Sensor   SensorType
+
+	// Stay zones are not monitored when their partition is armed in stay
+	// mode.
+	Stay bool
+}
+
+// Programming is how the alarm system is programmed.
+type Programming struct {
+	Zones []ZoneProgramming
+
+	// EntryDelays are indexed by partition.
+	EntryDelays []time.Duration
+	ExitDelay   time.Duration
+}
+
+// inPartition tells whether the given zone, from 1 to 64, belongs to the
+// given partition, or to all of them.
+func (p Programming) inPartition(zone int, partition byte) bool {
+	if zone < 1 || zone > len(p.Zones) {
+		return false
+	}
+	n := p.Zones[zone-1].Partition
+	return n == 0 || partition == AllPartitions || n == int(partition)
+}
+
+const (
+	zoneProgrammingSize = 4
+	programmingSize     = maxZones*zoneProgrammingSize + maxPartitions + 1
+)
+
+// Programming reads how zones, partitions and delays are programmed in the
+// alarm system.
+func (c *Client) Programming() (Programming, error) {
+	return c.ProgrammingContext(context.Background())
+}
+
+func (c *Client) ProgrammingContext(ctx context.Context) (Programming, error) {
+	log.Debug("programming")
+	reply, err := c.request(ctx, cmdProgramming, nil)
+	if err != nil {
+		return Programming{}, fmt.Errorf("could not read programming: %w", err)
+	}
+	if err := checkReply(cmdProgramming, reply); err != nil {
+		return Programming{}, fmt.Errorf("could not read programming: %w", err)
+	}
+	return programmingFromBytes(reply.Data)
+}
+
+// programmingFromBytes decodes the programming:
+//
+//	zones (64 * 4) | entry delays (16) | exit delay
+//
+// Each zone being:
+//
+//	partition | function | sensor type | flags
+//
+// Where the lowest flags bit tells whether it is a stay zone, and delays are
+// in seconds.
+func programmingFromBytes(resp []byte) (Programming, error) {
+	if len(resp) != programmingSize {
+		return Programming{}, fmt.Errorf("invalid programming size: %d", len(resp))
+	}
+
+	prog := Programming{
+		Zones:       make([]ZoneProgramming, maxZones),
+		EntryDelays: make([]time.Duration, maxPartitions),
+	}
+	for i := range prog.Zones {
+		b := resp[i*zoneProgrammingSize : (i+1)*zoneProgrammingSize]
+		prog.Zones[i] = ZoneProgramming{
+			Number:    i + 1,
+			Partition: int(b[0]),
+			Function:  ZoneFunction(b[1]),
+			Sensor:    SensorType(b[2]),
+			Stay:      b[3]&0x01 > 0,
+		}
+	}
+	delays := resp[maxZones*zoneProgrammingSize:]
+	for i := range prog.EntryDelays {
+		prog.EntryDelays[i] = time.Duration(delays[i]) * time.Second
+	}
+	prog.ExitDelay = time.Duration(delays[maxPartitions]) * time.Second
+	return prog, nil
+}
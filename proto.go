@@ -24,7 +24,7 @@ const (
 	dstID = 0x0000
 )
 
-func makeAuthPayload(pwd string) []byte {
+func makeAuthData(pwd string) []byte {
 	contactID, err := contactIDEncode(pwd)
 	if err != nil {
 		panic(err)
@@ -32,21 +32,6 @@ func makeAuthPayload(pwd string) []byte {
 	payload := []byte{deviceType}
 	payload = append(payload, contactID...)
 	payload = append(payload, softwareVersion)
-	return makePayload(cmdAuth, payload)
-}
-
-func makePayload(cmd int, input []byte) []byte {
-	dstID := splitIntoOctets(dstID)
-	srcID := splitIntoOctets(srcID)
-	length := splitIntoOctets(len(input) + 2)
-	cmdEnc := splitIntoOctets(cmd)
-	payload := []byte{}
-	payload = append(payload, dstID...)
-	payload = append(payload, srcID...)
-	payload = append(payload, length...)
-	payload = append(payload, cmdEnc...)
-	payload = append(payload, input...)
-	payload = append(payload, checksum(payload))
 	return payload
 }
 
@@ -96,31 +81,20 @@ func contactIDEncode(pwd string) ([]byte, error) {
 
 var ErrInvalidPassword = errors.New("invalid password")
 
-func parseAuthResponse(buf []byte) error {
-	cmd, result := parseResponse(buf)
-	if cmd != 0xf0f0 {
-		return fmt.Errorf("invalid command: %v", cmd)
+func parseAuthResponse(reply Frame) error {
+	if reply.Cmd != cmdAuth {
+		return UnexpectedCommandError{Want: cmdAuth, Got: reply.Cmd}
 	}
-	if len(result) == 0 {
-		return fmt.Errorf("invalid response: %v", result)
+	if len(reply.Data) == 0 {
+		return fmt.Errorf("invalid response: %v", reply.Data)
 	}
 
-	switch result[0] {
+	switch reply.Data[0] {
 	case 0:
 		return nil
 	case 1:
 		return ErrInvalidPassword
 	default:
-		return fmt.Errorf("authentication failed: %v", result[0])
-	}
-}
-
-func parseResponse(buf []byte) (int, []byte) {
-	lenPayload := mergeOctets(buf[4:6]) - 2
-	cmd := mergeOctets(buf[6:8])
-	if len(buf) < 8+lenPayload || lenPayload < 0 {
-		return cmd, nil
+		return fmt.Errorf("authentication failed: %v", reply.Data[0])
 	}
-	payload := buf[8 : 8+lenPayload]
-	return cmd, payload
 }
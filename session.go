@@ -0,0 +1,241 @@
+package amt8000
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrSessionClosed = errors.New("session is closed")
+
+// Session keeps a single authenticated connection to the alarm system open
+// and shares it between callers.
+//
+// Requests are serialized, the connection is kept alive while idle, and it is
+// transparently re-established (and re-authenticated) after a failure.
+// Events sent by the alarm system through it are available in Events.
+type Session struct {
+	host      string
+	port      string
+	pass      string
+	timeout   time.Duration
+	keepAlive time.Duration
+	loc       *time.Location
+
+	lock     chan struct{}
+	cli      *Client
+	lastUsed time.Time
+	closed   bool
+	done     chan struct{}
+	events   chan Event
+}
+
+// NewSession creates a new session.
+// It connects lazily, on the first call to Do.
+// If keepAlive is greater than zero, an idle connection will be pinged at that
+// interval, so the alarm system doesn't drop it, and a dropped connection will
+// be re-established at that interval, so events keep coming.
+func NewSession(host, port, pass string, timeout, keepAlive time.Duration) *Session {
+	s := &Session{
+		host:      host,
+		port:      port,
+		pass:      pass,
+		timeout:   timeout,
+		keepAlive: keepAlive,
+		loc:       time.Local,
+		lock:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		events:    make(chan Event, 64),
+	}
+	if keepAlive > 0 {
+		go s.keepAliveLoop()
+	}
+	return s
+}
+
+// Do runs fn with a connected client.
+// If fn fails with anything other than an error reported by the alarm system
+// itself, the connection is dropped and re-created on the next call.
+func (s *Session) Do(fn func(cli *Client) error) error {
+	return s.DoContext(context.Background(), fn)
+}
+
+// DoContext is like Do, but gives up waiting for the connection and for
+// previous requests once the context is done.
+// fn should use the context aware client methods with the same context.
+func (s *Session) DoContext(ctx context.Context, fn func(cli *Client) error) error {
+	if err := s.acquire(ctx); err != nil {
+		return err
+	}
+	defer s.release()
+
+	cli, err := s.client(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { s.lastUsed = time.Now() }()
+
+	if err := fn(cli); err != nil {
+		if !isPanelError(err) {
+			s.reset()
+		}
+		return err
+	}
+	return nil
+}
+
+// Events returns the events sent by the alarm system.
+// Events are dropped if they are not consumed fast enough.
+// The channel is closed when the session is closed.
+func (s *Session) Events() <-chan Event {
+	return s.events
+}
+
+// Close disconnects from the alarm system and stops the keep alive loop.
+// The session can't be used after it's closed.
+func (s *Session) Close() error {
+	s.lock <- struct{}{}
+	defer s.release()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	defer close(s.events)
+
+	if s.cli == nil {
+		return nil
+	}
+	err := s.cli.Close()
+	s.cli = nil
+	return err
+}
+
+// SetLocation sets the timezone of the alarm system clock and event log,
+// which is time.Local by default.
+func (s *Session) SetLocation(loc *time.Location) {
+	s.lock <- struct{}{}
+	defer s.release()
+	s.loc = loc
+	if s.cli != nil {
+		s.cli.SetLocation(loc)
+	}
+}
+
+func (s *Session) acquire(ctx context.Context) error {
+	select {
+	case s.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Session) release() {
+	<-s.lock
+}
+
+func (s *Session) client(ctx context.Context) (*Client, error) {
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+	if s.cli != nil && s.cli.broken() {
+		log.Debug("connection lost", "err", s.cli.err)
+		s.reset()
+	}
+	if s.cli != nil {
+		return s.cli, nil
+	}
+
+	cli := newClient(s.host, s.port, s.pass, s.timeout)
+	cli.loc = s.loc
+	cli.onEvent = s.publish
+	if err := cli.init(ctx); err != nil {
+		return nil, err
+	}
+	log.Debug("connected")
+	s.cli = cli
+	return cli, nil
+}
+
+func (s *Session) publish(event Event) {
+	select {
+	case s.events <- event:
+	default:
+		log.Warn("events buffer is full, dropping event", "event", event)
+	}
+}
+
+func (s *Session) reset() {
+	if s.cli == nil {
+		return
+	}
+	log.Debug("dropping connection")
+	if err := s.cli.Close(); err != nil {
+		log.Debug("could not close connection", "err", err)
+	}
+	s.cli = nil
+}
+
+func (s *Session) keepAliveLoop() {
+	tick := time.NewTicker(s.keepAlive)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-tick.C:
+			s.ping()
+		}
+	}
+}
+
+func (s *Session) ping() {
+	// don't queue behind other requests, the connection is in use anyway.
+	select {
+	case s.lock <- struct{}{}:
+	default:
+		return
+	}
+	defer s.release()
+
+	// never connected, nothing to keep alive.
+	if s.lastUsed.IsZero() || s.closed {
+		return
+	}
+
+	if s.cli == nil || s.cli.broken() {
+		log.Debug("reconnecting")
+		ctx, cancel := context.WithTimeout(context.Background(), s.keepAlive)
+		defer cancel()
+		if _, err := s.client(ctx); err != nil {
+			log.Warn("could not reconnect", "err", err)
+			return
+		}
+		s.lastUsed = time.Now()
+		return
+	}
+
+	if time.Since(s.lastUsed) < s.keepAlive {
+		return
+	}
+	log.Debug("keep alive")
+	if _, err := s.cli.Status(); err != nil {
+		log.Warn("keep alive failed", "err", err)
+		s.reset()
+		return
+	}
+	s.lastUsed = time.Now()
+}
+
+// isPanelError tells whether the error was a proper reply from the alarm
+// system, in which case the connection is still good to use.
+func isPanelError(err error) bool {
+	var refused RefusedError
+	return errors.As(err, &refused) ||
+		errors.Is(err, ErrInvalidZone) ||
+		errors.Is(err, ErrInvalidPartition) ||
+		errors.Is(err, ErrInvalidPGM) ||
+		errors.Is(err, ErrInvalidUser)
+}
@@ -0,0 +1,55 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+)
+
+const signalLevelsSize = maxZones + 2 + 2
+
+// SignalLevels reads the RF signal level of the wireless devices into the
+// given status.
+// The command is not documented: older firmwares refuse it, and others might
+// ignore it or reply with a layout we don't know, so it should be taken as
+// best-effort.
+func (c *Client) SignalLevels(status *Status) error {
+	return c.SignalLevelsContext(context.Background(), status)
+}
+
+func (c *Client) SignalLevelsContext(ctx context.Context, status *Status) error {
+	log.Debug("signal levels")
+	reply, err := c.request(ctx, cmdSignalLevels, nil)
+	if err != nil {
+		return fmt.Errorf("could not read signal levels: %w", err)
+	}
+	if err := checkReply(cmdSignalLevels, reply); err != nil {
+		return fmt.Errorf("could not read signal levels: %w", err)
+	}
+	if err := signalLevelsFromBytes(reply.Data, status); err != nil {
+		return fmt.Errorf("could not read signal levels: %w", err)
+	}
+	return nil
+}
+
+// signalLevelsFromBytes decodes one level per byte, from 0 to 10:
+//
+//	zones (64) | sirens (2) | repeaters (2)
+func signalLevelsFromBytes(resp []byte, status *Status) error {
+	if len(resp) != signalLevelsSize {
+		return fmt.Errorf("invalid signal levels size: %d", len(resp))
+	}
+	for i := range status.Zones {
+		status.Zones[i].SignalLevel = signalLevel(resp[i])
+	}
+	for i := range status.Sirens {
+		status.Sirens[i].SignalLevel = signalLevel(resp[maxZones+i])
+	}
+	for i := range status.Repeaters {
+		status.Repeaters[i].SignalLevel = signalLevel(resp[maxZones+2+i])
+	}
+	return nil
+}
+
+func signalLevel(b byte) int {
+	return min(int(b), 10)
+}
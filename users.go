@@ -0,0 +1,129 @@
+package amt8000
+
+import (
+	"context"
+	"fmt"
+)
+
+// User is a user programmed in the alarm system.
+// Passwords can be set but never read back.
+type User struct {
+	Number  int
+	Enabled bool
+
+	// Partitions the user can arm and disarm.
+	Partitions []int
+}
+
+const userSize = 3
+
+const (
+	userDisable = 0x00
+	userSet     = 0x01
+)
+
+// Users reads all the users programmed in the alarm system, enabled or not.
+func (c *Client) Users() ([]User, error) {
+	return c.UsersContext(context.Background())
+}
+
+func (c *Client) UsersContext(ctx context.Context) ([]User, error) {
+	log.Debug("users")
+	reply, err := c.request(ctx, cmdUsers, []byte{0x00, maxUsers})
+	if err != nil {
+		return nil, fmt.Errorf("could not read users: %w", err)
+	}
+	if err := checkReply(cmdUsers, reply); err != nil {
+		return nil, fmt.Errorf("could not read users: %w", err)
+	}
+	return usersFromBytes(reply.Data)
+}
+
+// SetUser enables the given user, from 1 to 98, with the given 6 digits
+// password, allowing them to arm and disarm the given partitions.
+// It either adds a new user or changes an existing one.
+func (c *Client) SetUser(user int, password string, partitions []int) error {
+	return c.SetUserContext(context.Background(), user, password, partitions)
+}
+
+func (c *Client) SetUserContext(ctx context.Context, user int, password string, partitions []int) error {
+	log.Debug("set user", "user", user, "partitions", partitions)
+	if user < 1 || user > maxUsers {
+		return fmt.Errorf("could not set user %v: %w", user, ErrInvalidUser)
+	}
+	if len(password) != 6 {
+		return fmt.Errorf("could not set user %v: password must have 6 digits", user)
+	}
+	digits, err := contactIDEncode(password)
+	if err != nil {
+		return fmt.Errorf("could not set user %v: password must have only digits", user)
+	}
+	var mask int
+	for _, part := range partitions {
+		if part < 0 || part >= maxPartitions {
+			return fmt.Errorf("could not set user %v: %w", user, ErrInvalidPartition)
+		}
+		mask |= 1 << part
+	}
+
+	data := append([]byte{userSet, byte(user - 1)}, splitIntoOctets(mask)...)
+	data = append(data, digits...)
+	reply, err := c.request(ctx, cmdUser, data)
+	if err != nil {
+		return fmt.Errorf("could not set user %v: %w", user, err)
+	}
+	if err := checkReply(cmdUser, reply); err != nil {
+		return fmt.Errorf("could not set user %v: %w", user, err)
+	}
+	return nil
+}
+
+// DisableUser disables the given user, from 1 to 98, so their password no
+// longer works.
+func (c *Client) DisableUser(user int) error {
+	return c.DisableUserContext(context.Background(), user)
+}
+
+func (c *Client) DisableUserContext(ctx context.Context, user int) error {
+	log.Debug("disable user", "user", user)
+	if user < 1 || user > maxUsers {
+		return fmt.Errorf("could not disable user %v: %w", user, ErrInvalidUser)
+	}
+	reply, err := c.request(ctx, cmdUser, []byte{userDisable, byte(user - 1)})
+	if err != nil {
+		return fmt.Errorf("could not disable user %v: %w", user, err)
+	}
+	if err := checkReply(cmdUser, reply); err != nil {
+		return fmt.Errorf("could not disable user %v: %w", user, err)
+	}
+	return nil
+}
+
+// usersFromBytes decodes the users, each of them being:
+//
+//	flags | partitions (2)
+//
+// The lowest flags bit tells whether the user is enabled, and partitions has
+// one bit per partition, partition 0 being the lowest one.
+func usersFromBytes(resp []byte) ([]User, error) {
+	if len(resp)%userSize != 0 {
+		return nil, fmt.Errorf("invalid users size: %d", len(resp))
+	}
+	var users []User
+	for i := 0; i < len(resp); i += userSize {
+		b := resp[i : i+userSize]
+		user := User{
+			Number:     i/userSize + 1,
+			Enabled:    b[0]&0x01 > 0,
+			Partitions: []int{},
+		}
+		mask := mergeOctets(b[1:3])
+		for part := 0; part < maxPartitions; part++ {
+			if mask&(1<<part) > 0 {
+				user.Partitions = append(user.Partitions, part)
+			}
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}